@@ -0,0 +1,126 @@
+package abi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	errNoFunction    = errors.New("invalid abi: function has no name")
+	errUnknownMethod = errors.New("function is not defined in abi")
+)
+
+// Kind is the kind of an ABI type
+type Kind int
+
+const (
+	Int32 Kind = iota
+	Int64
+	Uint32
+	Uint64
+	Bool
+	Bytes
+	String
+	Address
+	Array
+)
+
+var kindNames = map[string]Kind{
+	"int32":   Int32,
+	"int64":   Int64,
+	"uint32":  Uint32,
+	"uint64":  Uint64,
+	"bool":    Bool,
+	"bytes":   Bytes,
+	"string":  String,
+	"address": Address,
+}
+
+// Type is a parsed ABI type, Elem is the element type of an Array
+type Type struct {
+	Kind Kind
+	Elem *Type
+}
+
+// ParseType parses a type name such as "uint64", "address" or "bytes[]"
+func ParseType(name string) (*Type, error) {
+	if strings.HasSuffix(name, "[]") {
+		elem, err := ParseType(strings.TrimSuffix(name, "[]"))
+		if err != nil {
+			return nil, err
+		}
+		return &Type{Kind: Array, Elem: elem}, nil
+	}
+	kind, ok := kindNames[name]
+	if !ok {
+		return nil, fmt.Errorf("invalid abi: unknown type %q", name)
+	}
+	return &Type{Kind: kind}, nil
+}
+
+// Param is a named and typed parameter or return value of a function
+type Param struct {
+	Name string `json:"name,omitempty"`
+	Type string `json:"type"`
+}
+
+// Function describes an exported function of a contract
+type Function struct {
+	Name    string  `json:"name"`
+	Inputs  []Param `json:"inputs"`
+	Outputs []Param `json:"outputs,omitempty"`
+}
+
+// ABI describes the functions of a contract
+type ABI struct {
+	Functions []*Function `json:"functions"`
+}
+
+// Parse decodes a JSON ABI description and checks its types
+func Parse(data []byte) (*ABI, error) {
+	abi := &ABI{}
+	if err := json.Unmarshal(data, abi); err != nil {
+		return nil, fmt.Errorf("invalid abi: %v", err)
+	}
+	if err := abi.Validate(); err != nil {
+		return nil, err
+	}
+	return abi, nil
+}
+
+// Validate checks that function names are unique and all types are known
+func (abi *ABI) Validate() error {
+	names := make(map[string]bool)
+	for _, fn := range abi.Functions {
+		if fn == nil || fn.Name == "" {
+			return errNoFunction
+		}
+		if names[fn.Name] {
+			return fmt.Errorf("invalid abi: duplicate function %s", fn.Name)
+		}
+		names[fn.Name] = true
+		for _, param := range append(append([]Param{}, fn.Inputs...), fn.Outputs...) {
+			if _, err := ParseType(param.Type); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Marshal encodes abi as JSON
+func (abi *ABI) Marshal() ([]byte, error) {
+	return json.Marshal(abi)
+}
+
+// Function returns the function called name
+func (abi *ABI) Function(name string) (*Function, error) {
+	for _, fn := range abi.Functions {
+		if fn.Name == name {
+			return fn, nil
+		}
+	}
+	return nil, fmt.Errorf("%v: %s", errUnknownMethod, name)
+}
@@ -0,0 +1,52 @@
+package abi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testABI = `{"functions":[
+	{"name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint64"}],"outputs":[{"type":"bool"}]},
+	{"name":"batch","inputs":[{"name":"to","type":"address[]"},{"name":"amounts","type":"uint64[]"},{"name":"memo","type":"string"}]}
+]}`
+
+func TestParse(t *testing.T) {
+	abi, err := Parse([]byte(testABI))
+	assert.NoError(t, err)
+	assert.Len(t, abi.Functions, 2)
+
+	fn, err := abi.Function("transfer")
+	assert.NoError(t, err)
+	assert.Equal(t, "amount", fn.Inputs[1].Name)
+	_, err = abi.Function("unknown")
+	assert.Error(t, err)
+
+	data, err := abi.Marshal()
+	assert.NoError(t, err)
+	again, err := Parse(data)
+	assert.NoError(t, err)
+	assert.Equal(t, abi, again)
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, data := range []string{
+		`not json`,
+		`{"functions":[{"inputs":[]}]}`,
+		`{"functions":[{"name":"f"},{"name":"f"}]}`,
+		`{"functions":[{"name":"f","inputs":[{"type":"float"}]}]}`,
+		`{"functions":[{"name":"f","outputs":[{"type":"[]"}]}]}`,
+	} {
+		_, err := Parse([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
+
+func TestParseType(t *testing.T) {
+	typ, err := ParseType("bytes[][]")
+	assert.NoError(t, err)
+	assert.Equal(t, Array, typ.Kind)
+	assert.Equal(t, Array, typ.Elem.Kind)
+	assert.Equal(t, Bytes, typ.Elem.Elem.Kind)
+	assert.Equal(t, "bytes[][]", typ.String())
+}
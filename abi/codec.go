@@ -0,0 +1,356 @@
+package abi
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+var (
+	errShortData     = errors.New("unexpected end of data")
+	errTrailingData  = errors.New("unexpected data after value")
+	errEmptyAddress  = errors.New("empty address")
+	errInvalidBool   = errors.New("invalid bool")
+	errInvalidValues = errors.New("invalid length prefixed values")
+)
+
+// Encode encodes v as a value of type t.
+// Integers are little endian, bool is one byte and bytes, string and
+// address are raw bytes. An array is a uint32 element count followed by
+// the elements, each of them prefixed with its uint32 length unless the
+// element type has a fixed size.
+func Encode(t *Type, v interface{}) ([]byte, error) {
+	switch t.Kind {
+	case Int32:
+		if x, ok := v.(int32); ok {
+			return putUint32(uint32(x)), nil
+		}
+	case Uint32:
+		if x, ok := v.(uint32); ok {
+			return putUint32(x), nil
+		}
+	case Int64:
+		if x, ok := v.(int64); ok {
+			return putUint64(uint64(x)), nil
+		}
+	case Uint64:
+		if x, ok := v.(uint64); ok {
+			return putUint64(x), nil
+		}
+	case Bool:
+		if x, ok := v.(bool); ok {
+			if x {
+				return []byte{1}, nil
+			}
+			return []byte{0}, nil
+		}
+	case Bytes:
+		if x, ok := v.([]byte); ok {
+			return x, nil
+		}
+	case String:
+		if x, ok := v.(string); ok {
+			return []byte(x), nil
+		}
+	case Address:
+		if x, ok := v.([]byte); ok {
+			if len(x) == 0 {
+				return nil, errEmptyAddress
+			}
+			return x, nil
+		}
+	case Array:
+		return encodeArray(t, v)
+	}
+	return nil, fmt.Errorf("cannot encode %T as %s", v, t)
+}
+
+func encodeArray(t *Type, v interface{}) ([]byte, error) {
+	value := reflect.ValueOf(v)
+	if v == nil || value.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("cannot encode %T as %s", v, t)
+	}
+	out := putUint32(uint32(value.Len()))
+	for i := 0; i < value.Len(); i++ {
+		elem, err := Encode(t.Elem, value.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		if t.Elem.size() == 0 {
+			out = append(out, putUint32(uint32(len(elem)))...)
+		}
+		out = append(out, elem...)
+	}
+	return out, nil
+}
+
+// Decode decodes data which holds exactly one value of type t.
+// Arrays are decoded to slices, for example uint64[] to []uint64.
+func Decode(t *Type, data []byte) (interface{}, error) {
+	v, rest, err := decode(t, data, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errTrailingData
+	}
+	return v, nil
+}
+
+// decode decodes a value of type t from the head of data and returns the rest.
+// last tells that the value of a dynamic size type takes the whole data.
+func decode(t *Type, data []byte, last bool) (interface{}, []byte, error) {
+	if size := t.size(); size > 0 && len(data) < size {
+		return nil, nil, errShortData
+	}
+	switch t.Kind {
+	case Int32:
+		return int32(binary.LittleEndian.Uint32(data)), data[4:], nil
+	case Uint32:
+		return binary.LittleEndian.Uint32(data), data[4:], nil
+	case Int64:
+		return int64(binary.LittleEndian.Uint64(data)), data[8:], nil
+	case Uint64:
+		return binary.LittleEndian.Uint64(data), data[8:], nil
+	case Bool:
+		if data[0] > 1 {
+			return nil, nil, errInvalidBool
+		}
+		return data[0] == 1, data[1:], nil
+	}
+
+	if !last {
+		if len(data) < 4 {
+			return nil, nil, errShortData
+		}
+		n := int(binary.LittleEndian.Uint32(data))
+		data = data[4:]
+		if len(data) < n {
+			return nil, nil, errShortData
+		}
+		v, rest, err := decode(t, data[:n], true)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(rest) != 0 {
+			return nil, nil, errTrailingData
+		}
+		return v, data[n:], nil
+	}
+
+	switch t.Kind {
+	case Bytes:
+		return append([]byte{}, data...), nil, nil
+	case String:
+		return string(data), nil, nil
+	case Address:
+		if len(data) == 0 {
+			return nil, nil, errEmptyAddress
+		}
+		return types.Address(append([]byte{}, data...)), nil, nil
+	default:
+		return decodeArray(t, data)
+	}
+}
+
+func decodeArray(t *Type, data []byte) (interface{}, []byte, error) {
+	if len(data) < 4 {
+		return nil, nil, errShortData
+	}
+	n := int(binary.LittleEndian.Uint32(data))
+	data = data[4:]
+	if n > len(data) {
+		return nil, nil, errShortData
+	}
+	out := reflect.MakeSlice(reflect.SliceOf(t.Elem.goType()), 0, n)
+	for i := 0; i < n; i++ {
+		var elem interface{}
+		var err error
+		elem, data, err = decode(t.Elem, data, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		out = reflect.Append(out, reflect.ValueOf(elem))
+	}
+	return out.Interface(), data, nil
+}
+
+// size returns the encoded size of a fixed size type, 0 for dynamic ones
+func (t *Type) size() int {
+	switch t.Kind {
+	case Int32, Uint32:
+		return 4
+	case Int64, Uint64:
+		return 8
+	case Bool:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (t *Type) goType() reflect.Type {
+	switch t.Kind {
+	case Int32:
+		return reflect.TypeOf(int32(0))
+	case Uint32:
+		return reflect.TypeOf(uint32(0))
+	case Int64:
+		return reflect.TypeOf(int64(0))
+	case Uint64:
+		return reflect.TypeOf(uint64(0))
+	case Bool:
+		return reflect.TypeOf(false)
+	case String:
+		return reflect.TypeOf("")
+	case Bytes, Address:
+		return reflect.TypeOf([]byte{})
+	default:
+		return reflect.SliceOf(t.Elem.goType())
+	}
+}
+
+func (t *Type) String() string {
+	if t.Kind == Array {
+		return t.Elem.String() + "[]"
+	}
+	for name, kind := range kindNames {
+		if kind == t.Kind {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// Pack encodes values as the arguments of the function name
+func (abi *ABI) Pack(name string, values ...interface{}) (*types.CallInfo, error) {
+	fn, err := abi.Function(name)
+	if err != nil {
+		return nil, err
+	}
+	args, err := encodeParams(fn.Inputs, values)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return &types.CallInfo{Name: name, Args: args}, nil
+}
+
+// ValidateCall checks that ci calls a function of abi with arguments of the declared types
+func (abi *ABI) ValidateCall(ci *types.CallInfo) error {
+	fn, err := abi.Function(ci.GetName())
+	if err != nil {
+		return err
+	}
+	_, err = decodeParams(fn.Inputs, ci.GetArgs())
+	if err != nil {
+		return fmt.Errorf("%s: %v", ci.GetName(), err)
+	}
+	return nil
+}
+
+// Unpack decodes the return data of the function name.
+// Return data has the layout of the call arguments a contract receives:
+// a uint32 count followed by values prefixed with their uint32 length.
+func (abi *ABI) Unpack(name string, data []byte) ([]interface{}, error) {
+	fn, err := abi.Function(name)
+	if err != nil {
+		return nil, err
+	}
+	values, err := SplitValues(data)
+	if err != nil {
+		return nil, err
+	}
+	out, err := decodeParams(fn.Outputs, values)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return out, nil
+}
+
+// JoinValues lays out values the way call arguments are passed to a contract
+func JoinValues(values [][]byte) []byte {
+	out := putUint32(uint32(len(values)))
+	for _, v := range values {
+		out = append(out, putUint32(uint32(len(v)))...)
+		out = append(out, v...)
+	}
+	return out
+}
+
+// SplitValues is the inverse of JoinValues
+func SplitValues(data []byte) ([][]byte, error) {
+	if len(data) < 4 {
+		return nil, errInvalidValues
+	}
+	n := int(binary.LittleEndian.Uint32(data))
+	data = data[4:]
+	if n > len(data)/4 {
+		return nil, errInvalidValues
+	}
+	values := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		if len(data) < 4 {
+			return nil, errInvalidValues
+		}
+		size := int(binary.LittleEndian.Uint32(data))
+		data = data[4:]
+		if len(data) < size {
+			return nil, errInvalidValues
+		}
+		values = append(values, data[:size])
+		data = data[size:]
+	}
+	if len(data) != 0 {
+		return nil, errInvalidValues
+	}
+	return values, nil
+}
+
+func encodeParams(params []Param, values []interface{}) ([][]byte, error) {
+	if len(params) != len(values) {
+		return nil, fmt.Errorf("expected %d values, got %d", len(params), len(values))
+	}
+	out := make([][]byte, len(params))
+	for i, param := range params {
+		t, err := ParseType(param.Type)
+		if err != nil {
+			return nil, err
+		}
+		if out[i], err = Encode(t, values[i]); err != nil {
+			return nil, fmt.Errorf("value %d: %v", i, err)
+		}
+	}
+	return out, nil
+}
+
+func decodeParams(params []Param, data [][]byte) ([]interface{}, error) {
+	if len(params) != len(data) {
+		return nil, fmt.Errorf("expected %d values, got %d", len(params), len(data))
+	}
+	out := make([]interface{}, len(params))
+	for i, param := range params {
+		t, err := ParseType(param.Type)
+		if err != nil {
+			return nil, err
+		}
+		if out[i], err = Decode(t, data[i]); err != nil {
+			return nil, fmt.Errorf("value %d: %v", i, err)
+		}
+	}
+	return out, nil
+}
+
+func putUint32(v uint32) []byte {
+	out := make([]byte, 4)
+	binary.LittleEndian.PutUint32(out, v)
+	return out
+}
+
+func putUint64(v uint64) []byte {
+	out := make([]byte, 8)
+	binary.LittleEndian.PutUint64(out, v)
+	return out
+}
@@ -0,0 +1,103 @@
+package abi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	values := map[string]interface{}{
+		"int32":      int32(-7),
+		"uint32":     uint32(7),
+		"int64":      int64(-1 << 40),
+		"uint64":     uint64(1 << 63),
+		"bool":       true,
+		"bytes":      []byte{1, 2, 3},
+		"string":     "hello",
+		"address":    []byte("alice"),
+		"uint64[]":   []uint64{1, 2, 3},
+		"string[]":   []string{"a", "", "bc"},
+		"bool[][]":   [][]bool{{true}, {}, {false, true}},
+		"address[]":  [][]byte{[]byte("alice"), []byte("bob")},
+		"int32[]":    []int32{},
+		"bytes[][]":  [][][]byte{{{1}, {}}, {{2, 3}}},
+		"uint32[][]": [][]uint32{{1, 2}, {3}},
+	}
+	for name, v := range values {
+		typ, err := ParseType(name)
+		assert.NoError(t, err)
+		data, err := Encode(typ, v)
+		assert.NoError(t, err, name)
+		decoded, err := Decode(typ, data)
+		assert.NoError(t, err, name)
+		assert.Equal(t, v, decoded, name)
+	}
+}
+
+func TestEncodeMismatch(t *testing.T) {
+	typ, _ := ParseType("uint64")
+	_, err := Encode(typ, 1)
+	assert.Error(t, err)
+
+	typ, _ = ParseType("address")
+	_, err = Encode(typ, []byte{})
+	assert.Error(t, err)
+
+	typ, _ = ParseType("string[]")
+	_, err = Encode(typ, []int{1})
+	assert.Error(t, err)
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	cases := map[string][]byte{
+		"uint64":   {1, 2, 3},
+		"int32":    {1, 2, 3, 4, 5},
+		"bool":     {2},
+		"address":  {},
+		"uint32[]": {2, 0, 0, 0, 1, 0, 0, 0},
+		"bytes[]":  {1, 0, 0, 0, 5, 0, 0, 0, 1},
+	}
+	for name, data := range cases {
+		typ, _ := ParseType(name)
+		_, err := Decode(typ, data)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestPackValidateUnpack(t *testing.T) {
+	abi, err := Parse([]byte(testABI))
+	assert.NoError(t, err)
+
+	ci, err := abi.Pack("transfer", []byte("alice"), uint64(10))
+	assert.NoError(t, err)
+	assert.Equal(t, "transfer", ci.Name)
+	assert.NoError(t, abi.ValidateCall(ci))
+
+	_, err = abi.Pack("transfer", []byte("alice"))
+	assert.Error(t, err)
+	_, err = abi.Pack("transfer", "alice", uint64(10))
+	assert.Error(t, err)
+
+	assert.Error(t, abi.ValidateCall(&types.CallInfo{Name: "transfer", Args: [][]byte{[]byte("alice"), {1}}}))
+	assert.Error(t, abi.ValidateCall(&types.CallInfo{Name: "unknown"}))
+
+	out, err := abi.Unpack("transfer", JoinValues([][]byte{{1}}))
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{true}, out)
+	_, err = abi.Unpack("transfer", JoinValues([][]byte{{1}, {0}}))
+	assert.Error(t, err)
+	_, err = abi.Unpack("transfer", []byte{1, 0})
+	assert.Error(t, err)
+}
+
+func TestSplitValues(t *testing.T) {
+	values := [][]byte{{1, 2}, {}, []byte("abc")}
+	split, err := SplitValues(JoinValues(values))
+	assert.NoError(t, err)
+	assert.Equal(t, values, split)
+
+	_, err = SplitValues(append(JoinValues(values), 0))
+	assert.Error(t, err)
+}
@@ -32,3 +32,11 @@ func Sha3(data ...[]byte) []byte {
 	}
 	return hashes.Sum(nil)
 }
+
+func Keccak256(data ...[]byte) []byte {
+	hashes := sha3.NewLegacyKeccak256()
+	for i := 0; i < len(data); i++ {
+		hashes.Write(data[i])
+	}
+	return hashes.Sum(nil)
+}
@@ -0,0 +1,66 @@
+package contract
+
+import (
+	"github.com/go-interpreter/wagon/wasm"
+	"github.com/pkg/errors"
+	"github.com/zhigui-projects/zwasm/abi"
+	"github.com/zhigui-projects/zwasm/state"
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+// abiSection is the name of the custom section which carries the JSON ABI of a module
+const abiSection = "zwasm.abi"
+
+// deployedABI returns the ABI in the zwasm.abi custom section of the deployed
+// module m, or data when there is no such section, checked against the module exports.
+// m is nil when the deployment carries no module.
+func deployedABI(m *wasm.Module, data []byte) (*abi.ABI, []byte, error) {
+	if m != nil {
+		if section := m.Custom(abiSection); section != nil {
+			data = section.Data
+		}
+	}
+	contractABI, err := parseABI(data)
+	if err != nil || contractABI == nil || m == nil {
+		return contractABI, data, err
+	}
+	return contractABI, data, validateABI(m, contractABI)
+}
+
+// parseABI returns nil when data is empty
+func parseABI(data []byte) (*abi.ABI, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return abi.Parse(data)
+}
+
+// loadABI returns the ABI stored in crtState, nil if there is none
+func loadABI(crtState *state.ContractState) (*abi.ABI, error) {
+	data, err := crtState.GetABI()
+	if err != nil {
+		return nil, err
+	}
+	return parseABI(data)
+}
+
+// validateCall checks ci against the ABI of crtState, calls of contracts without ABI are not checked.
+func validateCall(crtState *state.ContractState, ci *types.CallInfo) error {
+	contractABI, err := loadABI(crtState)
+	if err != nil {
+		return err
+	}
+	return checkCall(contractABI, ci)
+}
+
+func checkCall(contractABI *abi.ABI, ci *types.CallInfo) error {
+	if contractABI == nil {
+		return nil
+	}
+	return errors.Wrap(contractABI.ValidateCall(ci), "invalid call")
+}
+
+// GetABI returns the ABI of the contract of crtState, nil if there is none
+func GetABI(crtState *state.ContractState) (*abi.ABI, error) {
+	return loadABI(crtState)
+}
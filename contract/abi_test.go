@@ -0,0 +1,137 @@
+package contract
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhigui-projects/zwasm/common"
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+const echoABI = `{"functions":[
+	{"name":"echo","inputs":[{"type":"uint64"},{"type":"string"}],"outputs":[{"type":"uint64"},{"type":"string"}]}
+]}`
+
+// echoModule returns its arguments as return data
+func echoModule() []byte {
+	m := newTestModule()
+	setReturn := m.importFunc("_set_return", []byte{i32, i32}, []byte{i32})
+	m.entry("echo", getLocal(0), getLocal(1), callFunc(setReturn), []byte{opI64ExtendU})
+	return m.bytes()
+}
+
+func TestCallABI(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+
+	crtState := openContract(t, manager, []byte("contract"))
+	_, err := Create(crtState, NewContext(WithGasLimit(10000), WithABI([]byte(echoABI))), deployCode(echoModule(), nil))
+	assert.NoError(t, err)
+
+	contractABI, err := GetABI(crtState)
+	assert.NoError(t, err)
+	assert.NotNil(t, contractABI)
+
+	ci, err := contractABI.Pack("echo", uint64(42), "hello")
+	assert.NoError(t, err)
+	ciBuf, _ := proto.Marshal(ci)
+	context := NewContext(WithGasLimit(10000), WithContractAddress([]byte("contract")))
+	receipt, err := Call(crtState, context, ciBuf)
+	assert.NoError(t, err)
+	out, err := contractABI.Unpack("echo", receipt.ReturnData)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{uint64(42), "hello"}, out)
+
+	ciBuf, _ = proto.Marshal(&types.CallInfo{Name: "echo", Args: [][]byte{{42}, []byte("hello")}})
+	receipt, err = Call(crtState, context, ciBuf)
+	assert.Error(t, err)
+	assert.Nil(t, receipt)
+
+	ciBuf, _ = proto.Marshal(&types.CallInfo{Name: "unknown"})
+	_, err = Query(crtState, context, ciBuf)
+	assert.Error(t, err)
+}
+
+func TestCreateABI(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+
+	crtState := openContract(t, manager, []byte("contract"))
+	_, err := Create(crtState, NewContext(WithGasLimit(10000), WithABI([]byte(`{"functions":[{"name":""}]}`))), deployCode(echoModule(), nil))
+	assert.Error(t, err)
+	assert.Nil(t, crtState.CodeHash)
+
+	initCall, _ := proto.Marshal(&types.CallInfo{Name: "echo"})
+	_, err = Create(crtState, NewContext(WithGasLimit(10000), WithABI([]byte(echoABI))), deployCode(echoModule(), initCall))
+	assert.Error(t, err)
+	assert.Nil(t, crtState.CodeHash)
+
+	_, err = Create(crtState, NewContext(WithGasLimit(10000)), deployCode(echoModule(), nil))
+	assert.NoError(t, err)
+	contractABI, err := GetABI(crtState)
+	assert.NoError(t, err)
+	assert.Nil(t, contractABI)
+}
+
+func TestCreateABISection(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+
+	m := newTestModule()
+	setReturn := m.importFunc("_set_return", []byte{i32, i32}, []byte{i32})
+	m.entry("echo", getLocal(0), getLocal(1), callFunc(setReturn), []byte{opI64ExtendU})
+	m.custom(abiSection, []byte(echoABI))
+	deployContract(t, manager, []byte("contract"), m.bytes())
+
+	data, err := manager.GetABI(types.ToAccountID([]byte("contract"), common.Sha3))
+	assert.NoError(t, err)
+	assert.Equal(t, echoABI, string(data))
+
+	contractABI, err := GetABI(openContract(t, manager, []byte("contract")))
+	assert.NoError(t, err)
+	ci, err := contractABI.Pack("echo", uint64(1), "abi")
+	assert.NoError(t, err)
+	ciBuf, _ := proto.Marshal(ci)
+	receipt, err := Call(openContract(t, manager, []byte("contract")), NewContext(WithGasLimit(10000)), ciBuf)
+	assert.NoError(t, err)
+	out, err := contractABI.Unpack("echo", receipt.ReturnData)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{uint64(1), "abi"}, out)
+}
+
+func TestCreateABIMismatch(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+
+	notExported := newTestModule()
+	notExported.entry("echo", i64Const(0))
+	notExported.custom(abiSection, []byte(`{"functions":[{"name":"other"}]}`))
+
+	badSignature := newTestModule()
+	badSignature.entry("echo", i64Const(0))
+	badSignature.function("other", nil, []byte{i64}, i64Const(0))
+	badSignature.custom(abiSection, []byte(`{"functions":[{"name":"other"}]}`))
+
+	invalid := newTestModule()
+	invalid.entry("echo", i64Const(0))
+	invalid.custom(abiSection, []byte(`{"functions":`))
+
+	for _, m := range []*testModule{notExported, badSignature, invalid} {
+		crtState := openContract(t, manager, []byte("contract"))
+		_, err := Create(crtState, NewContext(WithGasLimit(10000)), deployCode(m.bytes(), nil))
+		assert.Error(t, err)
+		assert.Nil(t, crtState.CodeHash)
+		data, err := crtState.GetABI()
+		assert.NoError(t, err)
+		assert.Nil(t, data)
+	}
+
+	crtState := openContract(t, manager, []byte("contract"))
+	_, err := Create(crtState, NewContext(WithGasLimit(10000), WithABI([]byte(`{"functions":[{"name":"other"}]}`))), deployCode(echoModule(), nil))
+	assert.EqualError(t, err, "invalid abi: function other is not exported")
+}
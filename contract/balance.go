@@ -0,0 +1,114 @@
+package contract
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+var (
+	errInsufficientBalance = errors.New("insufficient balance")
+	errBalanceOverflow     = errors.New("balance overflow")
+	errInvalidRecipient    = errors.New("invalid recipient address")
+)
+
+// balanceChange records the balance of an account state on the call stack
+// before it is changed by a transfer.
+type balanceChange struct {
+	state   *types.State
+	balance uint64
+}
+
+func (shim *externalResolver) root() *externalResolver {
+	r := shim
+	for r.parent != nil {
+		r = r.parent
+	}
+	return r
+}
+
+// setBalance changes the balance of st which is owned by an execution on the call stack.
+// The change is journaled to be reverted when the execution fails.
+func (shim *externalResolver) setBalance(st *types.State, balance uint64) {
+	root := shim.root()
+	root.journal = append(root.journal, balanceChange{state: st, balance: st.Balance})
+	st.Balance = balance
+}
+
+// journalMark is the length of the journals of the root resolver when a call starts
+type journalMark struct {
+	balances  int
+	destructs int
+}
+
+func (shim *externalResolver) mark() journalMark {
+	root := shim.root()
+	return journalMark{balances: len(root.journal), destructs: len(root.destructs)}
+}
+
+// revert reverts balance changes and self destructs journaled after mark
+func (shim *externalResolver) revert(mark journalMark) {
+	root := shim.root()
+	for i := len(root.journal) - 1; i >= mark.balances; i-- {
+		root.journal[i].state.Balance = root.journal[i].balance
+	}
+	root.journal = root.journal[:mark.balances]
+	root.destructs = root.destructs[:mark.destructs]
+}
+
+func (shim *externalResolver) balanceOf(addr []byte) (uint64, error) {
+	if st := shim.activeState(addr); st != nil {
+		return st.GetBalance(), nil
+	}
+	mgr := shim.context.manager
+	if mgr == nil {
+		return 0, errNoManager
+	}
+	rolled, err := mgr.GetRolledAccountState(addr)
+	if err != nil {
+		return 0, err
+	}
+	return rolled.Balance(), nil
+}
+
+// transfer moves amount from the executing contract to the account at to
+func (shim *externalResolver) transfer(to []byte, amount uint64) error {
+	if len(to) == 0 {
+		return errInvalidRecipient
+	}
+	self := shim.crtState.State
+	if self.Balance < amount {
+		return errInsufficientBalance
+	}
+	if bytes.Equal(to, shim.context.contractAddress) {
+		return nil
+	}
+
+	if recipient := shim.activeState(to); recipient != nil {
+		if recipient.Balance+amount < recipient.Balance {
+			return errBalanceOverflow
+		}
+		shim.setBalance(self, self.Balance-amount)
+		shim.setBalance(recipient.State, recipient.Balance+amount)
+		return nil
+	}
+
+	mgr := shim.context.manager
+	if mgr == nil {
+		return errNoManager
+	}
+	rolled, err := mgr.GetRolledAccountState(to)
+	if err != nil {
+		return err
+	}
+	if rolled.Balance()+amount < rolled.Balance() {
+		return errBalanceOverflow
+	}
+	rolled.AddBalance(amount)
+	if err = rolled.PutState(); err != nil {
+		return err
+	}
+	shim.setBalance(self, self.Balance-amount)
+	return nil
+}
@@ -0,0 +1,129 @@
+package contract
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+func balanceModule(to []byte, amount int64) []byte {
+	m := newTestModule()
+	balance := m.importFunc("_balance", []byte{i32, i32}, []byte{i64})
+	selfBalance := m.importFunc("_self_balance", nil, []byte{i64})
+	transfer := m.importFunc("_transfer", []byte{i32, i32, i64}, []byte{i32})
+	callValue := m.importFunc("_call_value", nil, []byte{i64})
+	m.putData(0, to)
+	m.entry("transfer", i32Const(0), i32Const(int32(len(to))), i64Const(amount), callFunc(transfer), []byte{opI64ExtendS})
+	m.entry("transferFail", i32Const(0), i32Const(int32(len(to))), i64Const(amount), callFunc(transfer), []byte{opDrop, opUnreachable})
+	m.entry("balance", i32Const(0), i32Const(int32(len(to))), callFunc(balance))
+	m.entry("selfBalance", callFunc(selfBalance))
+	m.entry("callValue", callFunc(callValue))
+	return m.bytes()
+}
+
+func callBalance(t *testing.T, context *Context, addr []byte, fn string) *Receipt {
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: fn})
+	receipt, err := Call(openContract(t, context.manager, addr), context, ciBuf)
+	assert.NoError(t, err)
+	return receipt
+}
+
+func fundContract(t *testing.T, context *Context, addr []byte, balance uint64) {
+	rolled, err := context.manager.GetRolledAccountState(addr)
+	assert.NoError(t, err)
+	rolled.AddBalance(balance)
+	assert.NoError(t, rolled.PutState())
+}
+
+func TestTransfer(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("bank"), balanceModule([]byte("alice"), 30))
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender"), contractAddress: []byte("bank"), manager: manager}
+	fundContract(t, context, []byte("bank"), 100)
+
+	crtState := openContract(t, manager, []byte("bank"))
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "transfer"})
+	receipt, err := Call(crtState, context, ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), receipt.Ret)
+	assert.Equal(t, uint64(70), crtState.GetBalance())
+
+	alice, err := manager.GetRolledAccountState([]byte("alice"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(30), alice.Balance())
+	assert.Equal(t, int64(30), callBalance(t, context, []byte("bank"), "balance").Ret)
+}
+
+func TestTransferInsufficientBalance(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("bank"), balanceModule([]byte("alice"), 30))
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender"), contractAddress: []byte("bank"), manager: manager}
+	fundContract(t, context, []byte("bank"), 10)
+
+	assert.Equal(t, int64(-1), callBalance(t, context, []byte("bank"), "transfer").Ret)
+	assert.Equal(t, int64(10), callBalance(t, context, []byte("bank"), "selfBalance").Ret)
+	assert.Equal(t, int64(0), callBalance(t, context, []byte("bank"), "balance").Ret)
+}
+
+func TestTransferOverflow(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("bank"), balanceModule([]byte("alice"), 30))
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender"), contractAddress: []byte("bank"), manager: manager}
+	fundContract(t, context, []byte("bank"), 100)
+	fundContract(t, context, []byte("alice"), ^uint64(0)-10)
+
+	assert.Equal(t, int64(-1), callBalance(t, context, []byte("bank"), "transfer").Ret)
+	assert.Equal(t, int64(100), callBalance(t, context, []byte("bank"), "selfBalance").Ret)
+}
+
+func TestTransferRevert(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("bank"), balanceModule([]byte("alice"), 30))
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender"), contractAddress: []byte("bank"), manager: manager}
+	fundContract(t, context, []byte("bank"), 100)
+
+	crtState := openContract(t, manager, []byte("bank"))
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "transferFail"})
+	receipt, err := Call(crtState, context, ciBuf)
+	assert.Error(t, err)
+	assert.Equal(t, ReceiptFailed, receipt.Status)
+	assert.Equal(t, uint64(100), crtState.GetBalance())
+
+	alice, err := manager.GetRolledAccountState([]byte("alice"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), alice.Balance())
+}
+
+func TestTransferReadOnly(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("bank"), balanceModule([]byte("alice"), 30))
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender"), contractAddress: []byte("bank"), manager: manager}
+	fundContract(t, context, []byte("bank"), 100)
+
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "transfer"})
+	receipt, err := Query(openContract(t, manager, []byte("bank")), context, ciBuf)
+	assert.Equal(t, errReadOnly, err)
+	assert.Equal(t, ReceiptFailed, receipt.Status)
+}
+
+func TestCallValue(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("bank"), balanceModule([]byte("alice"), 30))
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender"), contractAddress: []byte("bank"), manager: manager, value: 7}
+
+	assert.Equal(t, int64(7), callBalance(t, context, []byte("bank"), "callValue").Ret)
+}
@@ -0,0 +1,172 @@
+package contract
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/go-interpreter/wagon/wasm"
+	"github.com/perlin-network/life/compiler"
+	"github.com/perlin-network/life/exec"
+	"github.com/perlin-network/life/utils"
+)
+
+const defaultModuleCacheSize = 256 * 1024 * 1024
+
+var defaultModuleCache = NewModuleCache(defaultModuleCacheSize)
+
+// compiledModule is a parsed and compiled wasm module together with
+// its initial linear memory, globals and table.
+type compiledModule struct {
+	key          string
+	module       *compiler.Module
+	functionCode []compiler.InterpreterCode
+	memory       []byte
+	globals      []int64
+	table        []uint32
+	size         int
+}
+
+func newCompiledModule(key string, vm *exec.VirtualMachine, codeLen int) *compiledModule {
+	size := codeLen + len(vm.Memory) + 8*len(vm.Globals) + 4*len(vm.Table)
+	for _, code := range vm.FunctionCode {
+		size += len(code.Bytes)
+	}
+	return &compiledModule{
+		key:          key,
+		module:       vm.Module,
+		functionCode: vm.FunctionCode,
+		memory:       append([]byte{}, vm.Memory...),
+		globals:      append([]int64{}, vm.Globals...),
+		table:        append([]uint32{}, vm.Table...),
+		size:         size,
+	}
+}
+
+// instantiate creates a virtual machine of the module with its own memory, globals and table.
+func (m *compiledModule) instantiate(config exec.VMConfig, resolver exec.ImportResolver) (_ *exec.VirtualMachine, retErr error) {
+	defer utils.CatchPanic(&retErr)
+
+	imports := make([]exec.FunctionImport, 0)
+	if m.module.Base.Import != nil {
+		for _, imp := range m.module.Base.Import.Entries {
+			if imp.Type.Kind() == wasm.ExternalFunction {
+				imports = append(imports, resolver.ResolveFunc(imp.ModuleName, imp.FieldName))
+			}
+		}
+	}
+
+	return &exec.VirtualMachine{
+		Module:          m.module,
+		Config:          config,
+		FunctionCode:    m.functionCode,
+		FunctionImports: imports,
+		CallStack:       make([]exec.Frame, exec.DefaultCallStackSize),
+		CurrentFrame:    -1,
+		Table:           append([]uint32{}, m.table...),
+		Globals:         append([]int64{}, m.globals...),
+		Memory:          append([]byte{}, m.memory...),
+		Exited:          true,
+	}, nil
+}
+
+// CacheStats reports the usage of a ModuleCache
+type CacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+	Size    int
+}
+
+// ModuleCache is a LRU cache of compiled modules keyed by code hash.
+// The total size of cached modules is bounded by maxSize bytes.
+// It is safe for concurrent use.
+type ModuleCache struct {
+	lock    sync.Mutex
+	maxSize int
+	size    int
+	entries map[string]*list.Element
+	lru     *list.List
+	hits    uint64
+	misses  uint64
+}
+
+func NewModuleCache(maxSize int) *ModuleCache {
+	return &ModuleCache{
+		maxSize: maxSize,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (context *Context) getModuleCache() *ModuleCache {
+	if context.moduleCache == nil {
+		return defaultModuleCache
+	}
+	return context.moduleCache
+}
+
+// DefaultModuleCache returns the module cache shared by all executions
+func DefaultModuleCache() *ModuleCache {
+	return defaultModuleCache
+}
+
+func (cache *ModuleCache) get(key string) *compiledModule {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	elem, ok := cache.entries[key]
+	if !ok {
+		cache.misses++
+		return nil
+	}
+	cache.hits++
+	cache.lru.MoveToFront(elem)
+	return elem.Value.(*compiledModule)
+}
+
+func (cache *ModuleCache) put(m *compiledModule) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	if m.size > cache.maxSize {
+		return
+	}
+	if elem, ok := cache.entries[m.key]; ok {
+		cache.lru.MoveToFront(elem)
+		return
+	}
+	for cache.size+m.size > cache.maxSize {
+		cache.removeOldest()
+	}
+	cache.entries[m.key] = cache.lru.PushFront(m)
+	cache.size += m.size
+}
+
+func (cache *ModuleCache) removeOldest() {
+	elem := cache.lru.Back()
+	if elem == nil {
+		return
+	}
+	m := cache.lru.Remove(elem).(*compiledModule)
+	delete(cache.entries, m.key)
+	cache.size -= m.size
+}
+
+// Stats returns hit/miss counters and the current usage of cache
+func (cache *ModuleCache) Stats() CacheStats {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	return CacheStats{
+		Hits:    cache.hits,
+		Misses:  cache.misses,
+		Entries: cache.lru.Len(),
+		Size:    cache.size,
+	}
+}
+
+// Purge removes all compiled modules from cache
+func (cache *ModuleCache) Purge() {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	cache.entries = make(map[string]*list.Element)
+	cache.lru.Init()
+	cache.size = 0
+}
@@ -0,0 +1,63 @@
+package contract
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+func TestModuleCacheEviction(t *testing.T) {
+	cache := NewModuleCache(100)
+	cache.put(&compiledModule{key: "a", size: 40})
+	cache.put(&compiledModule{key: "b", size: 40})
+	assert.NotNil(t, cache.get("a"))
+
+	cache.put(&compiledModule{key: "c", size: 40})
+	assert.Nil(t, cache.get("b"))
+	assert.NotNil(t, cache.get("a"))
+	assert.NotNil(t, cache.get("c"))
+
+	cache.put(&compiledModule{key: "d", size: 200})
+	assert.Nil(t, cache.get("d"))
+
+	stats := cache.Stats()
+	assert.Equal(t, uint64(3), stats.Hits)
+	assert.Equal(t, uint64(2), stats.Misses)
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, 80, stats.Size)
+
+	cache.Purge()
+	assert.Equal(t, 0, cache.Stats().Entries)
+}
+
+func TestModuleCacheCall(t *testing.T) {
+	m := newTestModule()
+	set := m.importFunc("_set", []byte{i32, i32, i32, i32}, []byte{i32})
+	m.putData(0, []byte("keyvalue"))
+	m.entry("store", i32Const(0), i32Const(3), i32Const(3), i32Const(5), callFunc(set), []byte{opDrop}, i64Const(42))
+
+	store := createDB(t)
+	defer closeDB(t, store)
+	crtState, _ := createContractState(t, store)
+
+	cache := NewModuleCache(defaultModuleCacheSize)
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender"), moduleCache: cache}
+	_, err := Create(crtState, context, deployCode(m.bytes(), nil))
+	assert.NoError(t, err)
+
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "store"})
+	for i := 0; i < 3; i++ {
+		receipt, err := Call(crtState, context, ciBuf)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), receipt.Ret)
+	}
+	val, _ := crtState.GetData([]byte("key"))
+	assert.Equal(t, "value", string(val))
+
+	stats := cache.Stats()
+	assert.Equal(t, uint64(2), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, 1, stats.Entries)
+}
@@ -0,0 +1,132 @@
+package contract
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/perlin-network/life/exec"
+	"github.com/zhigui-projects/zwasm/state"
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+const defaultMaxCallDepth = 8
+
+var (
+	errNoManager     = errors.New("no state manager in context")
+	errMaxCallDepth  = errors.New("max call depth exceeded")
+	errInvalidCallee = errors.New("invalid callee address")
+)
+
+// callContract runs the marshaled CallInfo ci against the contract at addr
+// in a nested virtual machine with a gas budget of gas.
+// Changes of the callee are discarded when the nested execution fails.
+func (shim *externalResolver) callContract(addr []byte, ci []byte, gas uint64) (*Receipt, error) {
+	mgr := shim.context.manager
+	if mgr == nil {
+		return nil, errNoManager
+	}
+	if shim.depth+1 >= shim.context.getMaxCallDepth() {
+		return nil, errMaxCallDepth
+	}
+	if len(addr) == 0 {
+		return nil, errInvalidCallee
+	}
+
+	callInfo := &types.CallInfo{}
+	if err := proto.Unmarshal(ci, callInfo); err != nil {
+		return nil, errUnmarshalCall
+	}
+
+	// reuse the state of a contract which is already on the call stack,
+	// its owner commits it.
+	calleeState := shim.activeState(addr)
+	var rolled *state.RolledState
+	if calleeState == nil {
+		var err error
+		rolled, err = mgr.GetRolledAccountState(addr)
+		if err != nil {
+			return nil, err
+		}
+		calleeState, err = mgr.OpenContractState(rolled.State())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	code := getCode(calleeState, nil)
+	if code == nil {
+		return nil, errNoContract
+	}
+	if err := validateCall(calleeState, callInfo); err != nil {
+		return nil, err
+	}
+
+	context := *shim.context
+	context.gasLimit = gas
+	context.origin = shim.context.getOrigin()
+	context.senderAddress = shim.context.contractAddress
+	context.contractAddress = addr
+	context.value = 0
+	// contracts called by a start function can not change state either
+	if shim.checkWritable() != nil {
+		context.readOnly = true
+	}
+	callee := &externalResolver{
+		context:  &context,
+		crtState: calleeState,
+		parent:   shim,
+		depth:    shim.depth + 1,
+	}
+
+	snapshot := calleeState.Snapshot()
+	revision := mgr.Snapshot()
+	mark := shim.mark()
+	receipt, err := call(code, callInfo, callee)
+	if err != nil {
+		calleeState.Rollback(snapshot)
+		mgr.Rollback(revision)
+		shim.revert(mark)
+		return receipt, err
+	}
+
+	if rolled != nil && !shim.context.readOnly {
+		if err = mgr.CommitContractState(calleeState); err != nil {
+			return receipt, err
+		}
+		if err = rolled.PutState(); err != nil {
+			return receipt, err
+		}
+	}
+	shim.events = append(shim.events, receipt.Events...)
+	return receipt, nil
+}
+
+// forwardGas returns the gas budget for a nested call requesting gas.
+// zero requests all of the remaining gas of vm.
+func forwardGas(vm *exec.VirtualMachine, gas uint64) (uint64, bool) {
+	if vm.Gas >= vm.Config.GasLimit {
+		return 0, false
+	}
+	remain := vm.Config.GasLimit - vm.Gas
+	if gas == 0 || gas > remain {
+		gas = remain
+	}
+	return gas, true
+}
+
+func (shim *externalResolver) activeState(addr []byte) *state.ContractState {
+	for r := shim; r != nil; r = r.parent {
+		if bytes.Equal(r.context.contractAddress, addr) {
+			return r.crtState
+		}
+	}
+	return nil
+}
+
+func (context *Context) getMaxCallDepth() int {
+	if context.maxCallDepth <= 0 {
+		return defaultMaxCallDepth
+	}
+	return context.maxCallDepth
+}
@@ -0,0 +1,132 @@
+package contract
+
+import (
+	"testing"
+
+	"github.com/aergoio/aergo-lib/db"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhigui-projects/zwasm/common"
+	"github.com/zhigui-projects/zwasm/state"
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+func calleeModule() []byte {
+	m := newTestModule()
+	set := m.importFunc("_set", []byte{i32, i32, i32, i32}, []byte{i32})
+	m.putData(0, []byte("keyvalue"))
+	m.entry("store", i32Const(0), i32Const(3), i32Const(3), i32Const(5), callFunc(set), []byte{opDrop}, i64Const(42))
+	m.entry("fail", i32Const(0), i32Const(3), i32Const(3), i32Const(5), callFunc(set), []byte{opDrop}, []byte{opUnreachable})
+	return m.bytes()
+}
+
+// originModule stores the origin of the call as the value of the key "origin"
+func originModule() []byte {
+	m := newTestModule()
+	set := m.importFunc("_set", []byte{i32, i32, i32, i32}, []byte{i32})
+	origin := m.importFunc("_origin", []byte{i32}, []byte{i32})
+	m.putData(64, []byte("origin"))
+	m.entry("store", i32Const(64), i32Const(6), i32Const(0), i32Const(0), callFunc(origin), callFunc(set), []byte{opDrop}, i64Const(1))
+	return m.bytes()
+}
+
+func callerModule(callee []byte, fn string) []byte {
+	ci, _ := proto.Marshal(&types.CallInfo{Name: fn})
+	m := newTestModule()
+	callContract := m.importFunc("_call_contract", []byte{i32, i32, i32, i32, i64}, []byte{i64})
+	m.putData(0, callee)
+	m.putData(64, ci)
+	m.entry("invoke", i32Const(0), i32Const(int32(len(callee))), i32Const(64), i32Const(int32(len(ci))), i64Const(0), callFunc(callContract))
+	return m.bytes()
+}
+
+func deployContract(t *testing.T, manager *state.Manager, addr []byte, module []byte) {
+	rolled, err := manager.GetRolledAccountState(addr)
+	assert.NoError(t, err)
+	crtState, err := manager.OpenContractState(rolled.State())
+	assert.NoError(t, err)
+	_, err = Create(crtState, &Context{gasLimit: 10000, senderAddress: []byte("sender")}, deployCode(module, nil))
+	assert.NoError(t, err)
+	assert.NoError(t, manager.CommitContractState(crtState))
+	assert.NoError(t, rolled.PutState())
+}
+
+func openContract(t *testing.T, manager *state.Manager, addr []byte) *state.ContractState {
+	rolled, err := manager.GetRolledAccountState(addr)
+	assert.NoError(t, err)
+	crtState, err := manager.OpenContractState(rolled.State())
+	assert.NoError(t, err)
+	return crtState
+}
+
+func newTestManager(store db.DB) *state.Manager {
+	return state.NewManager(&store, nil, common.HashFuncFactory("sha3"))
+}
+
+func TestCallContract(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("callee"), calleeModule())
+	deployContract(t, manager, []byte("caller"), callerModule([]byte("callee"), "store"))
+
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender"), contractAddress: []byte("caller"), manager: manager}
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "invoke"})
+	receipt, err := Call(openContract(t, manager, []byte("caller")), context, ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), receipt.Ret)
+
+	val, err := openContract(t, manager, []byte("callee")).GetData([]byte("key"))
+	assert.NoError(t, err)
+	assert.Equal(t, "value", string(val))
+}
+
+func TestCallContractFail(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("callee"), calleeModule())
+	deployContract(t, manager, []byte("caller"), callerModule([]byte("callee"), "fail"))
+
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender"), contractAddress: []byte("caller"), manager: manager}
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "invoke"})
+	receipt, err := Call(openContract(t, manager, []byte("caller")), context, ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), receipt.Ret)
+
+	val, err := openContract(t, manager, []byte("callee")).GetData([]byte("key"))
+	assert.NoError(t, err)
+	assert.Nil(t, val)
+}
+
+func TestCallContractDepth(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("callee"), calleeModule())
+	deployContract(t, manager, []byte("caller"), callerModule([]byte("callee"), "store"))
+
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender"), contractAddress: []byte("caller"), manager: manager, maxCallDepth: 1}
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "invoke"})
+	receipt, err := Call(openContract(t, manager, []byte("caller")), context, ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), receipt.Ret)
+}
+
+func TestCallContractOrigin(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("callee"), originModule())
+	deployContract(t, manager, []byte("caller"), callerModule([]byte("callee"), "store"))
+
+	context := &Context{gasLimit: 10000, senderAddress: []byte("alice"), contractAddress: []byte("caller"), manager: manager}
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "invoke"})
+	receipt, err := Call(openContract(t, manager, []byte("caller")), context, ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), receipt.Ret)
+
+	val, err := openContract(t, manager, []byte("callee")).GetData([]byte("origin"))
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", string(val))
+}
@@ -0,0 +1,122 @@
+package contract
+
+// chainHostModule provides balances, transfers and the environment of the transaction
+var chainHostModule = NewHostModule(ChainModule, map[string]*HostFunction{
+	"_balance":       {Params: []ValueType{I32, I32}, Results: []ValueType{I64}, Cost: HostCost{Base: 20, PerByte: 1}, Func: hostBalance},
+	"_self_balance":  {Results: []ValueType{I64}, Cost: HostCost{Base: 2}, Func: hostSelfBalance},
+	"_call_value":    {Results: []ValueType{I64}, Cost: HostCost{Base: 2}, Func: hostCallValue},
+	"_transfer":      {Params: []ValueType{I32, I32, I64}, Results: []ValueType{I32}, Cost: HostCost{Base: 50, PerByte: 1}, Writes: true, Func: hostTransfer},
+	"_self_destruct": {Params: []ValueType{I32, I32}, Results: []ValueType{I32}, Cost: HostCost{Base: 100, PerByte: 1}, Writes: true, Func: hostSelfDestruct},
+	"_sender":        {Params: []ValueType{I32}, Results: []ValueType{I32}, Cost: HostCost{Base: 2, PerByte: 1}, Func: envBytes(func(c *Context) []byte { return c.senderAddress })},
+	"_origin":        {Params: []ValueType{I32}, Results: []ValueType{I32}, Cost: HostCost{Base: 2, PerByte: 1}, Func: envBytes((*Context).getOrigin)},
+	"_self_address":  {Params: []ValueType{I32}, Results: []ValueType{I32}, Cost: HostCost{Base: 2, PerByte: 1}, Func: envBytes(func(c *Context) []byte { return c.contractAddress })},
+	"_tx_hash":       {Params: []ValueType{I32}, Results: []ValueType{I32}, Cost: HostCost{Base: 2, PerByte: 1}, Func: envBytes(func(c *Context) []byte { return c.txHash })},
+	"_block_height":  {Results: []ValueType{I64}, Cost: HostCost{Base: 2}, Func: hostBlockHeight},
+	"_block_time":    {Results: []ValueType{I64}, Cost: HostCost{Base: 2}, Func: hostBlockTime},
+})
+
+// hostBalance (addrPtr, addrLen) -> i64 returns the balance of the account
+func hostBalance(call *HostCall) (int64, error) {
+	shim := call.resolver
+	addrLen := call.ptrArg(1)
+	addr, err := memoryRange(call.vm, call.ptrArg(0), addrLen)
+	if err != nil {
+		return 0, err
+	}
+	if err = call.useGas(addrLen, false); err != nil {
+		return 0, err
+	}
+
+	balance, err := shim.balanceOf(addr)
+	if err != nil {
+		shim.context.getLogger().Error().Err(err).Msgf("failed to get balance of %x", addr)
+		return -1, nil
+	}
+	return int64(balance), nil
+}
+
+// hostSelfBalance () -> i64 returns the balance of the contract
+func hostSelfBalance(call *HostCall) (int64, error) {
+	if err := call.useGas(0, false); err != nil {
+		return 0, err
+	}
+	return int64(call.resolver.crtState.GetBalance()), nil
+}
+
+// hostCallValue () -> i64 returns the amount transferred by the call
+func hostCallValue(call *HostCall) (int64, error) {
+	if err := call.useGas(0, false); err != nil {
+		return 0, err
+	}
+	return int64(call.resolver.context.value), nil
+}
+
+// hostTransfer (toPtr, toLen, amount) -> i32 transfers amount from the contract to the account
+func hostTransfer(call *HostCall) (int64, error) {
+	shim := call.resolver
+	toLen := call.ptrArg(1)
+	to, err := memoryRange(call.vm, call.ptrArg(0), toLen)
+	if err != nil {
+		return 0, err
+	}
+	amount := uint64(call.Arg(2))
+	if err = call.useGas(toLen, false); err != nil {
+		return 0, err
+	}
+
+	if err = shim.transfer(to, amount); err != nil {
+		shim.context.getLogger().Error().Err(err).Msgf("failed to transfer %d to %x", amount, to)
+		return -1, nil
+	}
+	return 1, nil
+}
+
+// hostSelfDestruct (benPtr, benLen) -> i32 removes the contract, its balance goes to the beneficiary
+func hostSelfDestruct(call *HostCall) (int64, error) {
+	shim := call.resolver
+	benLen := call.ptrArg(1)
+	beneficiary, err := memoryRange(call.vm, call.ptrArg(0), benLen)
+	if err != nil {
+		return 0, err
+	}
+	if err = call.useGas(benLen, false); err != nil {
+		return 0, err
+	}
+
+	if err = shim.selfDestruct(beneficiary); err != nil {
+		shim.context.getLogger().Error().Err(err).Msgf("failed to self destruct to %x", beneficiary)
+		return -1, nil
+	}
+	return 1, nil
+}
+
+// envBytes returns a host function (outPtr) -> i32 which writes the value
+// of the context to outPtr and returns its length.
+func envBytes(value func(context *Context) []byte) HostFunc {
+	return func(call *HostCall) (int64, error) {
+		data := value(call.resolver.context)
+		if err := call.useGas(len(data), false); err != nil {
+			return 0, err
+		}
+		if err := writeMemory(call.vm, call.ptrArg(0), data); err != nil {
+			return 0, err
+		}
+		return int64(len(data)), nil
+	}
+}
+
+// hostBlockHeight () -> i64 returns the height of the block
+func hostBlockHeight(call *HostCall) (int64, error) {
+	if err := call.useGas(0, false); err != nil {
+		return 0, err
+	}
+	return int64(call.resolver.context.blockHeight), nil
+}
+
+// hostBlockTime () -> i64 returns the unix time of the block
+func hostBlockTime(call *HostCall) (int64, error) {
+	if err := call.useGas(0, false); err != nil {
+		return 0, err
+	}
+	return call.resolver.context.blockTime, nil
+}
@@ -0,0 +1,152 @@
+package contract
+
+import (
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/zhigui-projects/zwasm/state"
+)
+
+// Option configures a Context
+type Option func(*Context)
+
+// NewContext returns a Context configured by opts.
+// The gas limit is zero unless WithGasLimit is given, a limit of zero allows no gas
+// to be used, so only deployments without an init call or a start function succeed.
+func NewContext(opts ...Option) *Context {
+	context := &Context{}
+	for _, opt := range opts {
+		opt(context)
+	}
+	return context
+}
+
+// WithGasLimit sets the gas budget of the execution
+func WithGasLimit(gasLimit uint64) Option {
+	return func(context *Context) {
+		context.gasLimit = gasLimit
+	}
+}
+
+// WithSender sets the address of the caller
+func WithSender(sender []byte) Option {
+	return func(context *Context) {
+		context.senderAddress = sender
+	}
+}
+
+// WithOrigin sets the address of the account which signed the transaction
+func WithOrigin(origin []byte) Option {
+	return func(context *Context) {
+		context.origin = origin
+	}
+}
+
+// WithContractAddress sets the address of the executed contract
+func WithContractAddress(addr []byte) Option {
+	return func(context *Context) {
+		context.contractAddress = addr
+	}
+}
+
+// WithValue sets the amount transferred to the contract by the call
+func WithValue(value uint64) Option {
+	return func(context *Context) {
+		context.value = value
+	}
+}
+
+// WithBlock sets the height and the unix time of the block including the transaction
+func WithBlock(height uint64, time int64) Option {
+	return func(context *Context) {
+		context.blockHeight = height
+		context.blockTime = time
+	}
+}
+
+// WithTxHash sets the hash of the transaction
+func WithTxHash(txHash []byte) Option {
+	return func(context *Context) {
+		context.txHash = txHash
+	}
+}
+
+// WithStateManager sets the manager of the account states,
+// it is required for contract calls and transfers.
+func WithStateManager(manager *state.Manager) Option {
+	return func(context *Context) {
+		context.manager = manager
+	}
+}
+
+// WithGasSchedule sets the gas costs, DefaultGasSchedule is used by default
+func WithGasSchedule(schedule *GasSchedule) Option {
+	return func(context *Context) {
+		context.gasSchedule = schedule
+	}
+}
+
+// WithModuleCache sets the cache of compiled modules, DefaultModuleCache is used by default
+func WithModuleCache(cache *ModuleCache) Option {
+	return func(context *Context) {
+		context.moduleCache = cache
+	}
+}
+
+// WithHostRegistry sets the host modules contracts can import, DefaultHostRegistry is used by default
+func WithHostRegistry(registry *HostRegistry) Option {
+	return func(context *Context) {
+		context.hostRegistry = registry
+	}
+}
+
+// WithStartWrites allows the start function of a deployed module to change state
+func WithStartWrites(allow bool) Option {
+	return func(context *Context) {
+		context.startWrites = allow
+	}
+}
+
+// WithLogger sets the logger of the execution, the global zerolog logger is used by default
+func WithLogger(logger zerolog.Logger) Option {
+	return func(context *Context) {
+		context.logger = &logger
+	}
+}
+
+// WithABI sets the JSON ABI which Create stores for a module without
+// a zwasm.abi custom section, calls of the contract are validated against it.
+func WithABI(abi []byte) Option {
+	return func(context *Context) {
+		context.abi = abi
+	}
+}
+
+// WithTracer records host calls, storage accesses and traps of the executions into tracer
+func WithTracer(tracer *Tracer) Option {
+	return func(context *Context) {
+		context.tracer = tracer
+	}
+}
+
+// WithMaxCallDepth limits the depth of nested contract calls
+func WithMaxCallDepth(depth int) Option {
+	return func(context *Context) {
+		context.maxCallDepth = depth
+	}
+}
+
+// getOrigin returns the account which signed the transaction,
+// the sender is the origin when it is not set.
+func (context *Context) getOrigin() []byte {
+	if context.origin == nil {
+		return context.senderAddress
+	}
+	return context.origin
+}
+
+func (context *Context) getLogger() *zerolog.Logger {
+	if context.logger == nil {
+		return &log.Logger
+	}
+	return context.logger
+}
@@ -0,0 +1,68 @@
+package contract
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+func TestNewContext(t *testing.T) {
+	schedule := DefaultGasSchedule()
+	cache := NewModuleCache(1024)
+	registry := NewHostRegistry()
+	context := NewContext(
+		WithGasLimit(100),
+		WithSender([]byte("sender")),
+		WithContractAddress([]byte("contract")),
+		WithValue(5),
+		WithBlock(10, 1600000000),
+		WithTxHash([]byte("txhash")),
+		WithGasSchedule(schedule),
+		WithModuleCache(cache),
+		WithMaxCallDepth(3),
+		WithHostRegistry(registry),
+	)
+	assert.Equal(t, uint64(100), context.gasLimit)
+	assert.Equal(t, []byte("sender"), context.senderAddress)
+	assert.Equal(t, []byte("sender"), context.getOrigin())
+	assert.Equal(t, []byte("contract"), context.contractAddress)
+	assert.Equal(t, uint64(5), context.value)
+	assert.Equal(t, uint64(10), context.blockHeight)
+	assert.Equal(t, int64(1600000000), context.blockTime)
+	assert.Equal(t, []byte("txhash"), context.txHash)
+	assert.Equal(t, schedule, context.getGasSchedule())
+	assert.Equal(t, cache, context.getModuleCache())
+	assert.Equal(t, 3, context.getMaxCallDepth())
+	assert.Equal(t, registry, context.getHostRegistry())
+
+	context = NewContext(WithOrigin([]byte("origin")))
+	assert.Equal(t, []byte("origin"), context.getOrigin())
+	assert.Equal(t, defaultGasSchedule, context.getGasSchedule())
+	assert.Equal(t, defaultModuleCache, context.getModuleCache())
+	assert.Equal(t, defaultHostRegistry, context.getHostRegistry())
+}
+
+func TestContextLogger(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("contract"), envModule())
+
+	var out bytes.Buffer
+	context := NewContext(
+		WithGasLimit(10000),
+		WithSender([]byte("sender")),
+		WithContractAddress([]byte("contract")),
+		WithStateManager(manager),
+		WithLogger(zerolog.New(&out)),
+	)
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "_sender"})
+	receipt, err := Call(openContract(t, manager, []byte("contract")), context, ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, "sender", string(receipt.ReturnData))
+	assert.Contains(t, out.String(), "Resolve func: env _sender")
+}
@@ -3,7 +3,9 @@ package contract
 import (
 	"errors"
 
+	"github.com/go-interpreter/wagon/wasm"
 	"github.com/golang/protobuf/proto"
+	"github.com/rs/zerolog"
 	"github.com/zhigui-projects/zwasm/state"
 	"github.com/zhigui-projects/zwasm/types"
 )
@@ -15,46 +17,174 @@ var (
 	errGasExceed         = errors.New("gas limit exceed")
 )
 
+// Context is the environment of a contract execution, it is built with NewContext.
 type Context struct {
-	gasLimit      uint64
-	senderAddress []byte
+	gasLimit        uint64
+	senderAddress   []byte
+	contractAddress []byte
+	manager         *state.Manager
+	maxCallDepth    int
+	readOnly        bool
+	moduleCache     *ModuleCache
+	gasSchedule     *GasSchedule
+	value           uint64
+	origin          []byte
+	blockHeight     uint64
+	blockTime       int64
+	txHash          []byte
+	logger          *zerolog.Logger
+	abi             []byte
+	tracer          *Tracer
+	hostRegistry    *HostRegistry
+	startWrites     bool
 }
 
-func Create(crtState *state.ContractState, context *Context, code []byte) (int64, uint64, error) {
-	contract, codeLen, deployGas, err := setCode(crtState, code, context.gasLimit)
+// Create deploys code into crtState, runs the start function of the module if it has one
+// and then the init call if the code carries one. A receipt is returned together with the
+// error when either fails, in which case the deployment is discarded.
+func Create(crtState *state.ContractState, context *Context, code []byte) (receipt *Receipt, err error) {
+	codeHash := crtState.CodeHash
+	snapshot := crtState.Snapshot()
+	defer func() {
+		if err != nil {
+			crtState.Rollback(snapshot)
+			crtState.CodeHash = codeHash
+		}
+	}()
+
+	ci, err := initCallInfo(code)
+	if err != nil {
+		return nil, err
+	}
+	var m *wasm.Module
+	if module := deployedModule(code); module != nil {
+		if m, err = decodeModule(module); err != nil {
+			return nil, err
+		}
+		if err = validateModule(m, newExternalResolver(context, crtState), ci.GetName()); err != nil {
+			return nil, err
+		}
+	}
+	contractABI, abiData, err := deployedABI(m, context.abi)
+	if err != nil {
+		return nil, err
+	}
+	if ci != nil {
+		if err = checkCall(contractABI, ci); err != nil {
+			return nil, err
+		}
+	}
+
+	contract, _, deployGas, err := setCode(crtState, code, context)
 	if err != nil {
-		return 0, 0, err
+		return nil, err
+	}
+	if contractABI != nil {
+		if err = crtState.SetABI(abiData); err != nil {
+			return nil, err
+		}
 	}
 
 	crtState.SetData([]byte("Creator"), context.senderAddress)
+	start := hasStartFunction(m)
+	if ci == nil && !start {
+		return newReceipt(0, deployGas, nil, nil), nil
+	}
+
+	// the init call runs on the gas left after the deployment
+	initContext := *context
+	initContext.gasLimit -= deployGas
+	resolver := newExternalResolver(&initContext, crtState)
+	resolver.start = start
+	receipt, err = execute(resolver, contract, ci)
+	if receipt != nil {
+		receipt.GasUsed += deployGas
+	}
+	return receipt, err
+}
+
+// initCallInfo returns the init call carried after the code of a deployment, if any.
+func initCallInfo(code []byte) (*types.CallInfo, error) {
+	if len(code) <= 4 {
+		return nil, nil
+	}
+	codeLen := codeLength(code)
+	if uint32(len(code)) <= codeLen {
+		return nil, nil
+	}
 	ci := &types.CallInfo{}
-	if len(code) != int(codeLen) {
-		err = proto.Unmarshal(code[codeLen:], ci)
-		if err != nil {
-			return 0, 0, errUnmarshalInitCall
-		}
+	if err := proto.Unmarshal(code[codeLen:], ci); err != nil {
+		return nil, errUnmarshalInitCall
 	}
+	return ci, nil
+}
 
-	if ci == nil {
-		return 0, deployGas, nil
+// Call runs the function described by the marshaled CallInfo in code.
+// A receipt is returned together with the error when the execution fails,
+// in which case all state changes of the execution are discarded.
+func Call(crtState *state.ContractState, context *Context, code []byte) (*Receipt, error) {
+	contract := getCode(crtState, nil)
+
+	if contract == nil {
+		return nil, errNoContract
 	}
 
-	ret, callGas, err := call(contract, ci, newExternalResolver(context, crtState))
-	return ret, deployGas + callGas, err
+	ci := &types.CallInfo{}
+	err := proto.Unmarshal(code, ci)
+	if err != nil {
+		return nil, errUnmarshalCall
+	}
+	if err = validateCall(crtState, ci); err != nil {
+		return nil, err
+	}
+
+	return execute(newExternalResolver(context, crtState), contract, ci)
 }
 
-func Call(crtState *state.ContractState, context *Context, code []byte) (int64, uint64, error) {
+// Query runs the function described by the marshaled CallInfo in code without
+// modifying any state. Host functions which change state trap, so queries can
+// run concurrently, each on its own ContractState opened from a Manager.Clone().
+func Query(crtState *state.ContractState, context *Context, code []byte) (*Receipt, error) {
 	contract := getCode(crtState, nil)
 
 	if contract == nil {
-		return 0, 0, errNoContract
+		return nil, errNoContract
 	}
 
-	var ci *types.CallInfo
+	ci := &types.CallInfo{}
 	err := proto.Unmarshal(code, ci)
 	if err != nil {
-		return 0, 0, errUnmarshalCall
+		return nil, errUnmarshalCall
+	}
+	if err = validateCall(crtState, ci); err != nil {
+		return nil, err
+	}
+
+	queryContext := *context
+	queryContext.readOnly = true
+	return call(contract, ci, newExternalResolver(&queryContext, crtState))
+}
+
+// execute calls ci with resolver and rolls back its state, the balances and the accounts
+// changed by nested calls when the execution reverts, traps or runs out of gas.
+// Contracts which destructed themselves are removed when the execution succeeds.
+func execute(resolver *externalResolver, contract []byte, ci *types.CallInfo) (*Receipt, error) {
+	crtState, context := resolver.crtState, resolver.context
+	snapshot := crtState.Snapshot()
+	var revision state.Snapshot
+	if context.manager != nil {
+		revision = context.manager.Snapshot()
 	}
 
-	return call(contract, ci, newExternalResolver(context, crtState))
+	receipt, err := call(contract, ci, resolver)
+	if err != nil {
+		crtState.Rollback(snapshot)
+		if context.manager != nil {
+			context.manager.Rollback(revision)
+		}
+		resolver.revert(journalMark{})
+		return receipt, err
+	}
+	receipt.Destructed, err = resolver.applyDestructs()
+	return receipt, err
 }
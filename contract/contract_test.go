@@ -1,9 +1,12 @@
 package contract
 
 import (
+	"sync"
 	"testing"
+
+	"github.com/zhigui-projects/zwasm/common"
 	"github.com/zhigui-projects/zwasm/types"
-	"github.com/gogo/protobuf/proto"
+	"github.com/golang/protobuf/proto"
 	"encoding/binary"
 	"github.com/stretchr/testify/assert"
 )
@@ -29,8 +32,135 @@ func TestCreate(t *testing.T) {
 	crtState, _ := createContractState(t, store)
 
 	context := &Context{gasLimit: 10000, senderAddress: []byte("sender")}
-	_, gas, _ := Create(crtState, context, sTotalBytes)
-	assert.True(t, gas > uint64(sCodeLen/1024*gasByKBSize))
+	receipt, err := Create(crtState, context, sTotalBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, ReceiptSuccess, receipt.Status)
+	assert.True(t, receipt.GasUsed > uint64(sCodeLen/1024*gasByKBSize))
 	creator, _ := crtState.GetData([]byte("Creator"))
 	assert.Equal(t, context.senderAddress, creator)
 }
+
+func TestCallReceipt(t *testing.T) {
+	m := newTestModule()
+	emit := m.importFunc("_emit_event", []byte{i32, i32, i32, i32}, []byte{i32})
+	m.putData(0, []byte("called"))
+	m.entry("invoke", i32Const(0), i32Const(6), i32Const(0), i32Const(0), callFunc(emit), []byte{opDrop}, i64Const(7))
+
+	store := createDB(t)
+	defer closeDB(t, store)
+	crtState, _ := createContractState(t, store)
+
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender")}
+	receipt, err := Create(crtState, context, deployCode(m.bytes(), nil))
+	assert.NoError(t, err)
+	assert.Equal(t, ReceiptSuccess, receipt.Status)
+	assert.Empty(t, receipt.Events)
+
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "invoke"})
+	receipt, err = Call(crtState, context, ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, ReceiptSuccess, receipt.Status)
+	assert.Equal(t, int64(7), receipt.Ret)
+	assert.True(t, receipt.GasUsed > 0)
+	assert.Equal(t, []*Event{{Name: "called", Data: []byte{}}}, receipt.Events)
+}
+
+func revertModule() []byte {
+	m := newTestModule()
+	set := m.importFunc("_set", []byte{i32, i32, i32, i32}, []byte{i32})
+	revert := m.importFunc("_revert", []byte{i32, i32}, []byte{i32})
+	m.putData(0, []byte("keyvalue"))
+	m.putData(16, []byte("not allowed"))
+	storeKey := concat(i32Const(0), i32Const(3), i32Const(3), i32Const(5), callFunc(set), []byte{opDrop})
+	m.entry("revert", storeKey, i32Const(16), i32Const(11), callFunc(revert), []byte{opDrop}, i64Const(1))
+	m.entry("trap", storeKey, []byte{opUnreachable})
+	m.entry("loop", storeKey, infiniteLoop(), i64Const(1))
+	return m.bytes()
+}
+
+func TestCallRevert(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	crtState, _ := createContractState(t, store)
+
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender")}
+	_, err := Create(crtState, context, deployCode(revertModule(), nil))
+	assert.NoError(t, err)
+
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "revert"})
+	receipt, err := Call(crtState, context, ciBuf)
+	assert.Equal(t, &RevertError{Reason: "not allowed"}, err)
+	assert.Equal(t, ReceiptFailed, receipt.Status)
+	val, _ := crtState.GetData([]byte("key"))
+	assert.Nil(t, val)
+
+	ciBuf, _ = proto.Marshal(&types.CallInfo{Name: "trap"})
+	receipt, err = Call(crtState, context, ciBuf)
+	assert.Error(t, err)
+	assert.Equal(t, ReceiptFailed, receipt.Status)
+	val, _ = crtState.GetData([]byte("key"))
+	assert.Nil(t, val)
+
+	ciBuf, _ = proto.Marshal(&types.CallInfo{Name: "loop"})
+	receipt, err = Call(crtState, context, ciBuf)
+	assert.Equal(t, errGasExceed, err)
+	assert.Equal(t, context.gasLimit, receipt.GasUsed)
+	val, _ = crtState.GetData([]byte("key"))
+	assert.Nil(t, val)
+
+	creator, _ := crtState.GetData([]byte("Creator"))
+	assert.Equal(t, context.senderAddress, creator)
+}
+
+func TestCreateRevert(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	crtState, _ := createContractState(t, store)
+
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender")}
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "revert"})
+	_, err := Create(crtState, context, deployCode(revertModule(), ciBuf))
+	assert.Equal(t, &RevertError{Reason: "not allowed"}, err)
+	assert.Nil(t, crtState.CodeHash)
+	creator, _ := crtState.GetData([]byte("Creator"))
+	assert.Nil(t, creator)
+}
+
+func TestQuery(t *testing.T) {
+	m := newTestModule()
+	getLen := m.importFunc("_get_len", []byte{i32, i32}, []byte{i32})
+	set := m.importFunc("_set", []byte{i32, i32, i32, i32}, []byte{i32})
+	m.putData(0, []byte("keyvalue"))
+	m.entry("length", i32Const(0), i32Const(3), callFunc(getLen), []byte{opI64ExtendU})
+	m.entry("store", i32Const(0), i32Const(3), i32Const(3), i32Const(5), callFunc(set), []byte{opI64ExtendU})
+
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("contract"), m.bytes())
+	crtState := openContract(t, manager, []byte("contract"))
+	crtState.SetData([]byte("key"), []byte("value"))
+	assert.NoError(t, manager.CommitContractState(crtState))
+	assert.NoError(t, manager.PutState(types.ToAccountID([]byte("contract"), common.Sha3), crtState.State))
+	assert.NoError(t, manager.Update())
+	assert.NoError(t, manager.Commit())
+
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender")}
+	store2, _ := proto.Marshal(&types.CallInfo{Name: "store"})
+	receipt, err := Query(openContract(t, manager, []byte("contract")), context, store2)
+	assert.Equal(t, errReadOnly, err)
+	assert.Equal(t, ReceiptFailed, receipt.Status)
+
+	length, _ := proto.Marshal(&types.CallInfo{Name: "length"})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			receipt, err := Query(openContract(t, manager.Clone(), []byte("contract")), context, length)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(5), receipt.Ret)
+		}()
+	}
+	wg.Wait()
+}
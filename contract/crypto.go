@@ -0,0 +1,125 @@
+package contract
+
+import (
+	"errors"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/zhigui-projects/zwasm/common"
+	"golang.org/x/crypto/ed25519"
+)
+
+const (
+	hashLength         = 32
+	signatureLength    = 65
+	ecAddressLength    = 20
+	compactMagicOffset = 27
+)
+
+var errInvalidSignature = errors.New("invalid signature")
+
+// cryptoHostModule provides hashes and signature verification
+var cryptoHostModule = NewHostModule(CryptoModule, map[string]*HostFunction{
+	"_sha2_256":       {Params: []ValueType{I32, I32, I32}, Results: []ValueType{I32}, Cost: HostCost{Base: 30, PerByte: 1}, Func: hashFunc(common.Sha2)},
+	"_sha3_256":       {Params: []ValueType{I32, I32, I32}, Results: []ValueType{I32}, Cost: HostCost{Base: 30, PerByte: 1}, Func: hashFunc(common.Sha3)},
+	"_keccak256":      {Params: []ValueType{I32, I32, I32}, Results: []ValueType{I32}, Cost: HostCost{Base: 30, PerByte: 1}, Func: hashFunc(common.Keccak256)},
+	"_ecrecover":      {Params: []ValueType{I32, I32, I32}, Results: []ValueType{I32}, Cost: HostCost{Base: 3000}, Func: hostEcrecover},
+	"_ed25519_verify": {Params: []ValueType{I32, I32, I32, I32}, Results: []ValueType{I32}, Cost: HostCost{Base: 2000, PerByte: 1}, Func: hostEd25519Verify},
+})
+
+// hashFunc returns a host function (dataPtr, dataLen, outPtr) -> i32 which writes
+// the hash of the data to outPtr and returns the hash length.
+func hashFunc(hash func(data ...[]byte) []byte) HostFunc {
+	return func(call *HostCall) (int64, error) {
+		dataLen := call.ptrArg(1)
+		if err := call.useGas(dataLen, false); err != nil {
+			return 0, err
+		}
+
+		data, err := memoryRange(call.vm, call.ptrArg(0), dataLen)
+		if err != nil {
+			return 0, err
+		}
+		sum := hash(data)
+		if err = writeMemory(call.vm, call.ptrArg(2), sum); err != nil {
+			return 0, err
+		}
+		return int64(len(sum)), nil
+	}
+}
+
+// hostEcrecover (hashPtr, sigPtr, outPtr) -> i32 writes the address of the signer
+// to outPtr, it returns 0 when the signature is invalid.
+func hostEcrecover(call *HostCall) (int64, error) {
+	if err := call.useGas(0, false); err != nil {
+		return 0, err
+	}
+
+	hash, err := memoryRange(call.vm, call.ptrArg(0), hashLength)
+	if err != nil {
+		return 0, err
+	}
+	sig, err := memoryRange(call.vm, call.ptrArg(1), signatureLength)
+	if err != nil {
+		return 0, err
+	}
+	addr, err := ecrecover(hash, sig)
+	if err != nil {
+		call.resolver.context.getLogger().Debug().Err(err).Msg("failed to recover signer")
+		return 0, nil
+	}
+	if err = writeMemory(call.vm, call.ptrArg(2), addr); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// hostEd25519Verify (pubPtr, msgPtr, msgLen, sigPtr) -> i32 returns 1 when the signature is valid
+func hostEd25519Verify(call *HostCall) (int64, error) {
+	msgLen := call.ptrArg(2)
+	if err := call.useGas(msgLen, false); err != nil {
+		return 0, err
+	}
+
+	pub, err := memoryRange(call.vm, call.ptrArg(0), ed25519.PublicKeySize)
+	if err != nil {
+		return 0, err
+	}
+	msg, err := memoryRange(call.vm, call.ptrArg(1), msgLen)
+	if err != nil {
+		return 0, err
+	}
+	sig, err := memoryRange(call.vm, call.ptrArg(3), ed25519.SignatureSize)
+	if err != nil {
+		return 0, err
+	}
+	if !ed25519Verify(pub, msg, sig) {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+// ecrecover returns the 20 bytes address of the secp256k1 key which signed hash.
+// sig is r || s || v where v is the recovery id, either 0/1 or 27/28.
+func ecrecover(hash []byte, sig []byte) ([]byte, error) {
+	v := sig[signatureLength-1]
+	if v >= compactMagicOffset {
+		v -= compactMagicOffset
+	}
+	if v > 1 {
+		return nil, errInvalidSignature
+	}
+	compact := make([]byte, signatureLength)
+	compact[0] = compactMagicOffset + v
+	copy(compact[1:], sig[:signatureLength-1])
+
+	pub, _, err := ecdsa.RecoverCompact(compact, hash)
+	if err != nil {
+		return nil, err
+	}
+	addr := common.Keccak256(pub.SerializeUncompressed()[1:])
+	return addr[len(addr)-ecAddressLength:], nil
+}
+
+func ed25519Verify(pub []byte, msg []byte, sig []byte) bool {
+	return ed25519.Verify(ed25519.PublicKey(pub), msg, sig)
+}
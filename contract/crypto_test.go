@@ -0,0 +1,109 @@
+package contract
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhigui-projects/zwasm/common"
+	"github.com/zhigui-projects/zwasm/types"
+	"golang.org/x/crypto/ed25519"
+)
+
+func hashModule(field string, data []byte) []byte {
+	m := newTestModule()
+	setReturn := m.importFunc("_set_return", []byte{i32, i32}, []byte{i32})
+	hash := m.importFunc(field, []byte{i32, i32, i32}, []byte{i32})
+	m.putData(0, data)
+	m.entry("hash", i32Const(128), i32Const(0), i32Const(int32(len(data))), i32Const(128), callFunc(hash), callFunc(setReturn), []byte{opI64ExtendU})
+	return m.bytes()
+}
+
+// ecrecoverModule recovers the signer of hash and returns it in return data
+func ecrecoverModule(hash []byte, sig []byte) []byte {
+	m := newTestModule()
+	setReturn := m.importFunc("_set_return", []byte{i32, i32}, []byte{i32})
+	recover := m.importFunc("_ecrecover", []byte{i32, i32, i32}, []byte{i32})
+	m.putData(0, hash)
+	m.putData(32, sig)
+	m.entry("recover", i32Const(0), i32Const(32), i32Const(128), callFunc(recover), []byte{opDrop},
+		i32Const(128), i32Const(ecAddressLength), callFunc(setReturn), []byte{opDrop},
+		i32Const(0), i32Const(32), i32Const(128), callFunc(recover), []byte{opI64ExtendU})
+	return m.bytes()
+}
+
+func ed25519Module(pub []byte, msg []byte, sig []byte) []byte {
+	m := newTestModule()
+	verify := m.importFunc("_ed25519_verify", []byte{i32, i32, i32, i32}, []byte{i32})
+	m.putData(0, pub)
+	m.putData(32, sig)
+	m.putData(96, msg)
+	m.entry("verify", i32Const(0), i32Const(96), i32Const(int32(len(msg))), i32Const(32), callFunc(verify), []byte{opI64ExtendU})
+	return m.bytes()
+}
+
+func callCrypto(t *testing.T, module []byte, fn string) *Receipt {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("contract"), module)
+
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender"), contractAddress: []byte("contract"), manager: manager}
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: fn})
+	receipt, err := Call(openContract(t, manager, []byte("contract")), context, ciBuf)
+	assert.NoError(t, err)
+	return receipt
+}
+
+func TestHashFunctions(t *testing.T) {
+	data := []byte("abc")
+	keccak, _ := hex.DecodeString("4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45")
+	expected := map[string][]byte{
+		"_sha2_256":  common.Sha2(data),
+		"_sha3_256":  common.Sha3(data),
+		"_keccak256": keccak,
+	}
+	for field, hash := range expected {
+		receipt := callCrypto(t, hashModule(field, data), "hash")
+		assert.Equal(t, hash, receipt.ReturnData, field)
+	}
+}
+
+func TestHashGas(t *testing.T) {
+	short := callCrypto(t, hashModule("_sha2_256", []byte("abc")), "hash")
+	long := callCrypto(t, hashModule("_sha2_256", make([]byte, 67)), "hash")
+	assert.Equal(t, short.GasUsed+64, long.GasUsed)
+}
+
+func TestEcrecover(t *testing.T) {
+	key, err := secp256k1.GeneratePrivateKey()
+	assert.NoError(t, err)
+	hash := common.Keccak256([]byte("message"))
+	compact := ecdsa.SignCompact(key, hash, false)
+	sig := append(append([]byte{}, compact[1:]...), compact[0]-compactMagicOffset)
+	addr := common.Keccak256(key.PubKey().SerializeUncompressed()[1:])[12:]
+
+	receipt := callCrypto(t, ecrecoverModule(hash, sig), "recover")
+	assert.Equal(t, int64(1), receipt.Ret)
+	assert.Equal(t, addr, receipt.ReturnData)
+
+	sig[signatureLength-1] = 5
+	receipt = callCrypto(t, ecrecoverModule(hash, sig), "recover")
+	assert.Equal(t, int64(0), receipt.Ret)
+}
+
+func TestEd25519Verify(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+	msg := []byte("message")
+	sig := ed25519.Sign(priv, msg)
+
+	receipt := callCrypto(t, ed25519Module(pub, msg, sig), "verify")
+	assert.Equal(t, int64(1), receipt.Ret)
+
+	receipt = callCrypto(t, ed25519Module(pub, []byte("forged!"), sig), "verify")
+	assert.Equal(t, int64(0), receipt.Ret)
+}
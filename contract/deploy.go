@@ -0,0 +1,54 @@
+package contract
+
+import (
+	"github.com/zhigui-projects/zwasm/state"
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+// Deploy creates a contract of the sender of context at a fresh address.
+// The address is derived from the sender nonce, or from salt and code when salt is not nil.
+// The new account is put into the state manager of context unless the deployment fails,
+// in which case the nonce of the sender is not incremented either.
+func Deploy(context *Context, code []byte, salt []byte) (addr types.Address, receipt *Receipt, err error) {
+	mgr := context.manager
+	if mgr == nil {
+		return nil, nil, errNoManager
+	}
+	revision := mgr.Snapshot()
+	defer func() {
+		if err != nil {
+			mgr.Rollback(revision)
+		}
+	}()
+
+	var rolled *state.RolledState
+	if salt == nil {
+		rolled, err = mgr.CreateContractAccount(context.senderAddress)
+	} else {
+		rolled, err = mgr.CreateContractAccountWithSalt(context.senderAddress, salt, code)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	crtState, err := mgr.OpenContractState(rolled.State())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deployContext := *context
+	deployContext.contractAddress = rolled.ID()
+	receipt, err = Create(crtState, &deployContext, code)
+	if err != nil {
+		return nil, receipt, err
+	}
+	if containsAddress(receipt.Destructed, rolled.ID()) {
+		return rolled.ID(), receipt, nil
+	}
+	if err = mgr.CommitContractState(crtState); err != nil {
+		return nil, receipt, err
+	}
+	if err = rolled.PutState(); err != nil {
+		return nil, receipt, err
+	}
+	return rolled.ID(), receipt, nil
+}
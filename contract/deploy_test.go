@@ -0,0 +1,61 @@
+package contract
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhigui-projects/zwasm/common"
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+func TestDeploy(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	context := NewContext(WithGasLimit(10000), WithSender([]byte("creator")), WithStateManager(manager))
+	code := deployCode(versionModule(1, false, false), nil)
+
+	addr, receipt, err := Deploy(context, code, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, ReceiptSuccess, receipt.Status)
+	assert.Equal(t, types.NewContractAddress([]byte("creator"), 0, common.Sha3), addr)
+	assert.Equal(t, int64(1), callVersion(t, openContract(t, manager, addr)))
+
+	next, _, err := Deploy(context, code, nil)
+	assert.NoError(t, err)
+	assert.NotEqual(t, addr, next)
+
+	salted, _, err := Deploy(context, code, []byte("salt"))
+	assert.NoError(t, err)
+	assert.Equal(t, types.NewSaltedContractAddress([]byte("creator"), []byte("salt"), common.Sha3(code), common.Sha3), salted)
+	creator, err := openContract(t, manager, salted).GetData([]byte("Creator"))
+	assert.NoError(t, err)
+	assert.Equal(t, "creator", string(creator))
+
+	_, _, err = Deploy(context, code, []byte("salt"))
+	assert.Error(t, err)
+	owner, err := manager.GetRolledAccountState([]byte("creator"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), owner.State().GetNonce())
+}
+
+func TestDeployFail(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	context := NewContext(WithGasLimit(10000), WithSender([]byte("creator")), WithStateManager(manager))
+
+	initCall, _ := proto.Marshal(&types.CallInfo{Name: "unknown"})
+	_, _, err := Deploy(context, deployCode(versionModule(1, false, false), initCall), nil)
+	assert.Error(t, err)
+	owner, err := manager.GetRolledAccountState([]byte("creator"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), owner.State().GetNonce())
+	st, err := manager.GetState(types.ToAccountID(types.NewContractAddress([]byte("creator"), 0, common.Sha3), common.Sha3))
+	assert.NoError(t, err)
+	assert.Nil(t, st)
+
+	_, _, err = Deploy(NewContext(WithSender([]byte("creator"))), nil, nil)
+	assert.Equal(t, errNoManager, err)
+}
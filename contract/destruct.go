@@ -0,0 +1,62 @@
+package contract
+
+import (
+	"bytes"
+	"errors"
+)
+
+var errNoContractAddress = errors.New("no contract address in context")
+
+// selfDestruct moves the balance of the executing contract to beneficiary,
+// the contract is removed when the whole execution succeeds.
+// The balance is burned when the contract is its own beneficiary.
+func (shim *externalResolver) selfDestruct(beneficiary []byte) error {
+	// the account to delete is looked up by the contract address
+	if len(shim.context.contractAddress) == 0 {
+		return errNoContractAddress
+	}
+	if len(beneficiary) == 0 {
+		return errInvalidRecipient
+	}
+	if err := shim.transfer(beneficiary, shim.crtState.GetBalance()); err != nil {
+		return err
+	}
+	root := shim.root()
+	root.destructs = append(root.destructs, shim)
+	return nil
+}
+
+// applyDestructs clears the code, storage and balance of the destructed contracts
+// and deletes their accounts from the state manager. It returns their addresses.
+func (shim *externalResolver) applyDestructs() ([][]byte, error) {
+	var destructed [][]byte
+	for _, r := range shim.root().destructs {
+		addr := r.context.contractAddress
+		if containsAddress(destructed, addr) {
+			continue
+		}
+		destructed = append(destructed, addr)
+		if err := r.crtState.Clear(); err != nil {
+			return destructed, err
+		}
+		if mgr := shim.context.manager; mgr != nil {
+			rolled, err := mgr.GetRolledAccountState(addr)
+			if err != nil {
+				return destructed, err
+			}
+			if err = mgr.DeleteState(rolled.AccountID()); err != nil {
+				return destructed, err
+			}
+		}
+	}
+	return destructed, nil
+}
+
+func containsAddress(addrs [][]byte, addr []byte) bool {
+	for _, a := range addrs {
+		if bytes.Equal(a, addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package contract
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhigui-projects/zwasm/common"
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+func destructModule(beneficiary []byte) []byte {
+	m := newTestModule()
+	destruct := m.importFunc("_self_destruct", []byte{i32, i32}, []byte{i32})
+	m.putData(0, beneficiary)
+	m.entry("destruct", i32Const(0), i32Const(int32(len(beneficiary))), callFunc(destruct), []byte{opI64ExtendS})
+	m.entry("destructFail", i32Const(0), i32Const(int32(len(beneficiary))), callFunc(destruct), []byte{opDrop, opUnreachable})
+	return m.bytes()
+}
+
+func TestSelfDestruct(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("bank"), destructModule([]byte("heir")))
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender"), contractAddress: []byte("bank"), manager: manager}
+	fundContract(t, context, []byte("bank"), 100)
+
+	crtState := openContract(t, manager, []byte("bank"))
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "destruct"})
+	receipt, err := Call(crtState, context, ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), receipt.Ret)
+	assert.Equal(t, [][]byte{[]byte("bank")}, receipt.Destructed)
+	assert.Nil(t, crtState.CodeHash)
+	assert.Equal(t, uint64(0), crtState.GetBalance())
+
+	heir, err := manager.GetRolledAccountState([]byte("heir"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100), heir.Balance())
+	st, err := manager.GetState(types.ToAccountID([]byte("bank"), common.Sha3))
+	assert.NoError(t, err)
+	assert.Nil(t, st)
+}
+
+func TestSelfDestructFail(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("bank"), destructModule([]byte("heir")))
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender"), contractAddress: []byte("bank"), manager: manager}
+	fundContract(t, context, []byte("bank"), 100)
+
+	crtState := openContract(t, manager, []byte("bank"))
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "destructFail"})
+	receipt, err := Call(crtState, context, ciBuf)
+	assert.Error(t, err)
+	assert.Nil(t, receipt.Destructed)
+	assert.NotNil(t, crtState.CodeHash)
+	assert.Equal(t, uint64(100), crtState.GetBalance())
+
+	st, err := manager.GetState(types.ToAccountID([]byte("bank"), common.Sha3))
+	assert.NoError(t, err)
+	assert.NotNil(t, st)
+}
+
+func TestSelfDestructNoAddress(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("bank"), destructModule([]byte("heir")))
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender"), manager: manager}
+	fundContract(t, context, nil, 100)
+
+	crtState := openContract(t, manager, []byte("bank"))
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "destruct"})
+	receipt, err := Call(crtState, context, ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), receipt.Ret)
+	assert.Nil(t, receipt.Destructed)
+	assert.NotNil(t, crtState.CodeHash)
+
+	st, err := manager.GetState(types.ToAccountID(nil, common.Sha3))
+	assert.NoError(t, err)
+	assert.NotNil(t, st)
+}
+
+func TestSelfDestructNested(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("bank"), destructModule([]byte("caller")))
+	deployContract(t, manager, []byte("caller"), callerModule([]byte("bank"), "destruct"))
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender"), contractAddress: []byte("caller"), manager: manager}
+	fundContract(t, context, []byte("bank"), 100)
+
+	crtState := openContract(t, manager, []byte("caller"))
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "invoke"})
+	receipt, err := Call(crtState, context, ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("bank")}, receipt.Destructed)
+	assert.Equal(t, uint64(100), crtState.GetBalance())
+
+	st, err := manager.GetState(types.ToAccountID([]byte("bank"), common.Sha3))
+	assert.NoError(t, err)
+	assert.Nil(t, st)
+}
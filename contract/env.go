@@ -0,0 +1,220 @@
+package contract
+
+// envHostModule provides storage, events, nested calls and the result of the execution
+var envHostModule = NewHostModule(EnvModule, map[string]*HostFunction{
+	"_get_len":       {Params: []ValueType{I32, I32}, Results: []ValueType{I32}, Cost: HostCost{Base: 10, DirtyBase: 2}, Func: hostGetLen},
+	"_get":           {Params: []ValueType{I32, I32, I32}, Results: []ValueType{I32}, Cost: HostCost{Base: 10, DirtyBase: 2, PerByte: 1}, Func: hostGet},
+	"_get_buf":       {Params: []ValueType{I32, I32, I32, I32}, Results: []ValueType{I64}, Cost: HostCost{Base: 10, DirtyBase: 2, PerByte: 1}, Func: hostGetBuf},
+	"_set":           {Params: []ValueType{I32, I32, I32, I32}, Results: []ValueType{I32}, Cost: HostCost{Base: 20, DirtyBase: 5, PerByte: 2}, Writes: true, Func: hostSet},
+	"_delete":        {Params: []ValueType{I32, I32}, Results: []ValueType{I32}, Cost: HostCost{Base: 10, DirtyBase: 5}, Writes: true, Func: hostDelete},
+	"_emit_event":    {Params: []ValueType{I32, I32, I32, I32}, Results: []ValueType{I32}, Cost: HostCost{Base: 10, PerByte: 1}, Writes: true, Func: hostEmitEvent},
+	"_call_contract": {Params: []ValueType{I32, I32, I32, I32, I64}, Results: []ValueType{I64}, Cost: HostCost{Base: 50}, Func: hostCallContract},
+	"_set_return":    {Params: []ValueType{I32, I32}, Results: []ValueType{I32}, Cost: HostCost{Base: 1, PerByte: 1}, Func: hostSetReturn},
+	"_revert":        {Params: []ValueType{I32, I32}, Results: []ValueType{I32}, Cost: HostCost{Base: 1}, Func: hostRevert},
+})
+
+// hostGetLen (keyPtr, keyLen) -> i32 returns the length of the value of the key
+func hostGetLen(call *HostCall) (int64, error) {
+	shim := call.resolver
+	keyLen := call.ptrArg(1)
+	key, err := memoryRange(call.vm, call.ptrArg(0), keyLen)
+	if err != nil {
+		return 0, err
+	}
+	if err = call.useGas(keyLen, shim.crtState.IsDirty(key)); err != nil {
+		return 0, err
+	}
+
+	value, err := shim.crtState.GetData(key)
+	if err != nil {
+		shim.context.getLogger().Error().Err(err).Msg("_get_len")
+		return -1, nil
+	}
+	shim.traceStorage(StorageRead, key, nil, value)
+	return int64(len(value)), nil
+}
+
+// hostGet (keyPtr, keyLen, outPtr) -> i32 writes the value of the key to outPtr
+func hostGet(call *HostCall) (int64, error) {
+	shim := call.resolver
+	keyLen := call.ptrArg(1)
+	key, err := memoryRange(call.vm, call.ptrArg(0), keyLen)
+	if err != nil {
+		return 0, err
+	}
+
+	dirty := shim.crtState.IsDirty(key)
+	value, err := shim.crtState.GetData(key)
+	if gasErr := call.useGas(keyLen+len(value), dirty); gasErr != nil {
+		return 0, gasErr
+	}
+	if err != nil {
+		shim.context.getLogger().Error().Err(err).Msg("_get")
+		return -1, nil
+	}
+	shim.traceStorage(StorageRead, key, nil, value)
+	if err = writeMemory(call.vm, call.ptrArg(2), value); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// hostGetBuf (keyPtr, keyLen, outPtr, outCap) -> i64 writes at most outCap bytes of
+// the value of the key to outPtr and returns the length of the value.
+func hostGetBuf(call *HostCall) (int64, error) {
+	shim := call.resolver
+	keyLen := call.ptrArg(1)
+	key, err := memoryRange(call.vm, call.ptrArg(0), keyLen)
+	if err != nil {
+		return 0, err
+	}
+	out, err := memoryRange(call.vm, call.ptrArg(2), call.ptrArg(3))
+	if err != nil {
+		return 0, err
+	}
+
+	dirty := shim.crtState.IsDirty(key)
+	value, err := shim.crtState.GetData(key)
+	if gasErr := call.useGas(keyLen+len(value), dirty); gasErr != nil {
+		return 0, gasErr
+	}
+	if err != nil {
+		shim.context.getLogger().Error().Err(err).Msg("_get_buf")
+		return -1, nil
+	}
+	shim.traceStorage(StorageRead, key, nil, value)
+
+	// a length larger than outCap tells the value is truncated
+	copy(out, value)
+	return int64(len(value)), nil
+}
+
+// hostSet (keyPtr, keyLen, valuePtr, valueLen) -> i32 stores the value of the key
+func hostSet(call *HostCall) (int64, error) {
+	shim := call.resolver
+	keyLen := call.ptrArg(1)
+	key, err := memoryRange(call.vm, call.ptrArg(0), keyLen)
+	if err != nil {
+		return 0, err
+	}
+	valueLen := call.ptrArg(3)
+	value, err := readMemory(call.vm, call.ptrArg(2), valueLen)
+	if err != nil {
+		return 0, err
+	}
+	if err = call.useGas(keyLen+valueLen, shim.crtState.IsDirty(key)); err != nil {
+		return 0, err
+	}
+
+	old := shim.oldValue(key)
+	if err = shim.crtState.SetData(key, value); err != nil {
+		shim.context.getLogger().Error().Err(err).Msg("_set")
+		return -1, nil
+	}
+	shim.traceStorage(StorageWrite, key, old, value)
+	return 1, nil
+}
+
+// hostDelete (keyPtr, keyLen) -> i32 removes the key
+func hostDelete(call *HostCall) (int64, error) {
+	shim := call.resolver
+	keyLen := call.ptrArg(1)
+	key, err := memoryRange(call.vm, call.ptrArg(0), keyLen)
+	if err != nil {
+		return 0, err
+	}
+	if err = call.useGas(keyLen, shim.crtState.IsDirty(key)); err != nil {
+		return 0, err
+	}
+
+	old := shim.oldValue(key)
+	if err = shim.crtState.DeleteData(key); err != nil {
+		shim.context.getLogger().Error().Err(err).Msg("_delete")
+		return -1, nil
+	}
+	shim.traceStorage(StorageDelete, key, old, nil)
+	return 1, nil
+}
+
+// hostEmitEvent (namePtr, nameLen, dataPtr, dataLen) -> i32 adds an event to the receipt
+func hostEmitEvent(call *HostCall) (int64, error) {
+	nameLen := call.ptrArg(1)
+	name, err := memoryRange(call.vm, call.ptrArg(0), nameLen)
+	if err != nil {
+		return 0, err
+	}
+	dataLen := call.ptrArg(3)
+	if err = call.useGas(nameLen+dataLen, false); err != nil {
+		return 0, err
+	}
+	data, err := readMemory(call.vm, call.ptrArg(2), dataLen)
+	if err != nil {
+		return 0, err
+	}
+
+	shim := call.resolver
+	shim.events = append(shim.events, &Event{Name: string(name), Data: data})
+	return 1, nil
+}
+
+// hostCallContract (addrPtr, addrLen, ciPtr, ciLen, gas) -> i64 calls another contract
+// and returns its result, -1 when the call fails.
+func hostCallContract(call *HostCall) (int64, error) {
+	shim := call.resolver
+	addrLen := call.ptrArg(1)
+	addr, err := memoryRange(call.vm, call.ptrArg(0), addrLen)
+	if err != nil {
+		return 0, err
+	}
+	ciLen := call.ptrArg(3)
+	ci, err := memoryRange(call.vm, call.ptrArg(2), ciLen)
+	if err != nil {
+		return 0, err
+	}
+	if err = call.useGas(addrLen+ciLen, false); err != nil {
+		return 0, err
+	}
+
+	gas, ok := forwardGas(call.vm, uint64(call.Arg(4)))
+	if !ok {
+		shim.context.getLogger().Error().Err(errGasExceed).Msgf("failed to call contract %x", addr)
+		return -1, nil
+	}
+
+	receipt, err := shim.callContract(addr, ci, gas)
+	if receipt != nil {
+		call.vm.Gas += receipt.GasUsed
+	}
+	if err != nil {
+		shim.context.getLogger().Error().Err(err).Msgf("failed to call contract %x", addr)
+		return -1, nil
+	}
+	return receipt.Ret, nil
+}
+
+// hostSetReturn (ptr, len) -> i32 sets the return data of the execution
+func hostSetReturn(call *HostCall) (int64, error) {
+	dataLen := call.ptrArg(1)
+	if err := call.useGas(dataLen, false); err != nil {
+		return 0, err
+	}
+	data, err := readMemory(call.vm, call.ptrArg(0), dataLen)
+	if err != nil {
+		return 0, err
+	}
+	call.resolver.returnData = data
+	return 1, nil
+}
+
+// hostRevert (msgPtr, msgLen) aborts the execution with the message as reason
+func hostRevert(call *HostCall) (int64, error) {
+	msgLen := call.ptrArg(1)
+	msg, err := memoryRange(call.vm, call.ptrArg(0), msgLen)
+	if err != nil {
+		return 0, err
+	}
+	if err = call.useGas(msgLen, false); err != nil {
+		return 0, err
+	}
+	return 0, &RevertError{Reason: string(msg)}
+}
@@ -0,0 +1,71 @@
+package contract
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+// envModule returns the length of the env bytes of field, they are stored
+// in return data, or the value of an integer env function.
+func envModule() []byte {
+	m := newTestModule()
+	setReturn := m.importFunc("_set_return", []byte{i32, i32}, []byte{i32})
+	for _, field := range []string{"_sender", "_origin", "_self_address", "_tx_hash"} {
+		fn := m.importFunc(field, []byte{i32}, []byte{i32})
+		m.entry(field, i32Const(0), i32Const(0), callFunc(fn), callFunc(setReturn), []byte{opI64ExtendU})
+	}
+	height := m.importFunc("_block_height", nil, []byte{i64})
+	m.entry("_block_height", callFunc(height))
+	blockTime := m.importFunc("_block_time", nil, []byte{i64})
+	m.entry("_block_time", callFunc(blockTime))
+	return m.bytes()
+}
+
+func TestEnv(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("contract"), envModule())
+
+	context := &Context{
+		gasLimit:        10000,
+		senderAddress:   []byte("sender"),
+		contractAddress: []byte("contract"),
+		origin:          []byte("origin"),
+		blockHeight:     100,
+		blockTime:       1600000000,
+		txHash:          []byte("txhash"),
+		manager:         manager,
+	}
+	expected := map[string]string{
+		"_sender":       "sender",
+		"_origin":       "origin",
+		"_self_address": "contract",
+		"_tx_hash":      "txhash",
+	}
+	for field, value := range expected {
+		ciBuf, _ := proto.Marshal(&types.CallInfo{Name: field})
+		receipt, err := Call(openContract(t, manager, []byte("contract")), context, ciBuf)
+		assert.NoError(t, err)
+		assert.Equal(t, value, string(receipt.ReturnData), field)
+	}
+
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "_block_height"})
+	receipt, err := Call(openContract(t, manager, []byte("contract")), context, ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), receipt.Ret)
+
+	ciBuf, _ = proto.Marshal(&types.CallInfo{Name: "_block_time"})
+	receipt, err = Call(openContract(t, manager, []byte("contract")), context, ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1600000000), receipt.Ret)
+
+	context.origin = nil
+	ciBuf, _ = proto.Marshal(&types.CallInfo{Name: "_origin"})
+	receipt, err = Call(openContract(t, manager, []byte("contract")), context, ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, "sender", string(receipt.ReturnData))
+}
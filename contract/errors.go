@@ -0,0 +1,54 @@
+package contract
+
+import (
+	"fmt"
+)
+
+// RevertError is returned when a contract aborts its execution through _revert.
+// All state changes made by the execution are discarded.
+type RevertError struct {
+	Reason string
+}
+
+func (e *RevertError) Error() string {
+	return fmt.Sprintf("contract reverted: %s", e.Reason)
+}
+
+// MemoryAccessError is returned when a host function accesses Length bytes at
+// Offset outside the Size bytes of the contract memory. The contract traps.
+type MemoryAccessError struct {
+	Offset uint64
+	Length uint64
+	Size   int
+}
+
+func (e *MemoryAccessError) Error() string {
+	return fmt.Sprintf("memory access out of bounds: %d bytes at %d, memory size %d", e.Length, e.Offset, e.Size)
+}
+
+// ImportError is returned when a contract imports Field of Module which the host
+// does not provide. Kind is the kind of the import, function, global, memory or table.
+type ImportError struct {
+	Module string
+	Field  string
+	Kind   string
+}
+
+func (e *ImportError) Error() string {
+	if e.Kind == "" {
+		return fmt.Sprintf("invalid module: unknown import %s.%s", e.Module, e.Field)
+	}
+	return fmt.Sprintf("invalid module: unknown %s import %s.%s", e.Kind, e.Module, e.Field)
+}
+
+// HostError is returned when the host function Field of Module panics.
+// The contract traps.
+type HostError struct {
+	Module string
+	Field  string
+	Reason string
+}
+
+func (e *HostError) Error() string {
+	return fmt.Sprintf("host function %s.%s failed: %s", e.Module, e.Field, e.Reason)
+}
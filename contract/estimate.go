@@ -0,0 +1,76 @@
+package contract
+
+// defaultEstimateGasLimit is the gas budget of an estimation with a context
+// without gas limit, it bounds the time an estimation runs.
+const defaultEstimateGasLimit = 10000000
+
+// GasEstimate is the result of EstimateGas. Reverted tells that the execution
+// would fail, Reason is the error it would fail with.
+type GasEstimate struct {
+	GasUsed  uint64 `json:"gasUsed"`
+	Reverted bool   `json:"reverted"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// EstimateGas runs the call described by the marshaled CallInfo in code on the
+// contract at the address of context. The gas budget is the gas limit of context,
+// defaultEstimateGasLimit when it has none, running out of gas reverts. It runs on a
+// Manager.CloneOverlay() of the state manager of context, so changes are kept in
+// memory and thrown away, changes which are not committed to the manager yet are not visible.
+func EstimateGas(context *Context, code []byte) (*GasEstimate, error) {
+	estimateContext, err := newEstimateContext(context)
+	if err != nil {
+		return nil, err
+	}
+	mgr := estimateContext.manager
+	rolled, err := mgr.GetRolledAccountState(context.contractAddress)
+	if err != nil {
+		return nil, err
+	}
+	crtState, err := mgr.OpenContractState(rolled.State())
+	if err != nil {
+		return nil, err
+	}
+	return newGasEstimate(Call(crtState, estimateContext, code))
+}
+
+// EstimateDeployGas is like EstimateGas for a Deploy of code with salt by the sender of context,
+// the deployment cost is included.
+func EstimateDeployGas(context *Context, code []byte, salt []byte) (*GasEstimate, error) {
+	estimateContext, err := newEstimateContext(context)
+	if err != nil {
+		return nil, err
+	}
+	_, receipt, err := Deploy(estimateContext, code, salt)
+	if receipt == nil && err == errGasExceed {
+		// the deployment cost alone exceeds the budget
+		receipt = newReceipt(0, estimateContext.gasLimit, nil, err)
+	}
+	return newGasEstimate(receipt, err)
+}
+
+func newEstimateContext(context *Context) (*Context, error) {
+	if context.manager == nil {
+		return nil, errNoManager
+	}
+	estimateContext := *context
+	estimateContext.manager = context.manager.CloneOverlay()
+	if estimateContext.gasLimit == 0 {
+		estimateContext.gasLimit = defaultEstimateGasLimit
+	}
+	return &estimateContext, nil
+}
+
+// newGasEstimate reports the error as a revert when the execution ran,
+// errors which prevent the execution are returned.
+func newGasEstimate(receipt *Receipt, err error) (*GasEstimate, error) {
+	if receipt == nil {
+		return nil, err
+	}
+	estimate := &GasEstimate{GasUsed: receipt.GasUsed}
+	if err != nil {
+		estimate.Reverted = true
+		estimate.Reason = err.Error()
+	}
+	return estimate, nil
+}
@@ -0,0 +1,155 @@
+package contract
+
+import (
+	"testing"
+
+	"github.com/aergoio/aergo-lib/db"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+func TestEstimateGas(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("callee"), calleeModule())
+	deployContract(t, manager, []byte("caller"), callerModule([]byte("callee"), "store"))
+	assert.NoError(t, manager.Update())
+	assert.NoError(t, manager.Commit())
+
+	context := NewContext(WithSender([]byte("sender")), WithContractAddress([]byte("caller")), WithStateManager(manager))
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "invoke"})
+	estimate, err := EstimateGas(context, ciBuf)
+	assert.NoError(t, err)
+	assert.False(t, estimate.Reverted)
+	assert.True(t, estimate.GasUsed > 0)
+	val, err := openContract(t, manager, []byte("callee")).GetData([]byte("key"))
+	assert.NoError(t, err)
+	assert.Nil(t, val)
+
+	context = NewContext(WithSender([]byte("sender")), WithContractAddress([]byte("callee")), WithStateManager(manager))
+	ciBuf, _ = proto.Marshal(&types.CallInfo{Name: "store"})
+	estimate, err = EstimateGas(context, ciBuf)
+	assert.NoError(t, err)
+	assert.False(t, estimate.Reverted)
+
+	_, err = Call(openContract(t, manager, []byte("callee")), NewContext(WithGasLimit(estimate.GasUsed-1)), ciBuf)
+	assert.Equal(t, errGasExceed, err)
+	receipt, err := Call(openContract(t, manager, []byte("callee")), NewContext(WithGasLimit(estimate.GasUsed)), ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, estimate.GasUsed, receipt.GasUsed)
+}
+
+func TestEstimateGasRevert(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("contract"), revertModule())
+	assert.NoError(t, manager.Update())
+	assert.NoError(t, manager.Commit())
+
+	context := NewContext(WithSender([]byte("sender")), WithContractAddress([]byte("contract")), WithStateManager(manager))
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "revert"})
+	estimate, err := EstimateGas(context, ciBuf)
+	assert.NoError(t, err)
+	assert.True(t, estimate.Reverted)
+	assert.Equal(t, (&RevertError{Reason: "not allowed"}).Error(), estimate.Reason)
+	assert.True(t, estimate.GasUsed > 0)
+
+	ciBuf, _ = proto.Marshal(&types.CallInfo{Name: "unknown"})
+	_, err = EstimateGas(context, ciBuf)
+	assert.Error(t, err)
+
+	_, err = EstimateGas(NewContext(WithContractAddress([]byte("contract"))), ciBuf)
+	assert.Equal(t, errNoManager, err)
+}
+
+func TestEstimateDeployGas(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	schedule := DefaultGasSchedule()
+	schedule.DeployPerByte = 1
+	newDeployContext := func(gasLimit uint64) *Context {
+		return NewContext(WithGasLimit(gasLimit), WithSender([]byte("creator")), WithStateManager(manager), WithGasSchedule(schedule))
+	}
+	module := calleeModule()
+	initCall, _ := proto.Marshal(&types.CallInfo{Name: "store"})
+	code := deployCode(module, initCall)
+
+	estimate, err := EstimateDeployGas(newDeployContext(0), code, nil)
+	assert.NoError(t, err)
+	assert.False(t, estimate.Reverted)
+	assert.True(t, estimate.GasUsed > uint64(len(module)))
+	owner, err := manager.GetRolledAccountState([]byte("creator"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), owner.State().GetNonce())
+
+	_, _, err = Deploy(newDeployContext(estimate.GasUsed-1), code, nil)
+	assert.Equal(t, errGasExceed, err)
+	_, receipt, err := Deploy(newDeployContext(estimate.GasUsed), code, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, estimate.GasUsed, receipt.GasUsed)
+}
+
+func dumpDB(store db.DB) map[string]string {
+	entries := make(map[string]string)
+	for iter := store.Iterator(nil, nil); iter.Valid(); iter.Next() {
+		entries[string(iter.Key())] = string(iter.Value())
+	}
+	return entries
+}
+
+func TestEstimateGasDryRun(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("callee"), calleeModule())
+	deployContract(t, manager, []byte("caller"), callerModule([]byte("callee"), "store"))
+	assert.NoError(t, manager.Update())
+	assert.NoError(t, manager.Commit())
+	entries := dumpDB(store)
+
+	context := NewContext(WithSender([]byte("sender")), WithContractAddress([]byte("caller")), WithStateManager(manager))
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "invoke"})
+	estimate, err := EstimateGas(context, ciBuf)
+	assert.NoError(t, err)
+	assert.False(t, estimate.Reverted)
+	assert.Equal(t, entries, dumpDB(store))
+
+	initCall, _ := proto.Marshal(&types.CallInfo{Name: "store"})
+	estimate, err = EstimateDeployGas(NewContext(WithSender([]byte("creator")), WithStateManager(manager)), deployCode(calleeModule(), initCall), nil)
+	assert.NoError(t, err)
+	assert.False(t, estimate.Reverted)
+	assert.Equal(t, entries, dumpDB(store))
+}
+
+func TestEstimateGasLimit(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	m := newTestModule()
+	m.entry("loop", infiniteLoop(), i64Const(1))
+	deployContract(t, manager, []byte("contract"), m.bytes())
+	assert.NoError(t, manager.Update())
+	assert.NoError(t, manager.Commit())
+
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "loop"})
+	context := NewContext(WithSender([]byte("sender")), WithContractAddress([]byte("contract")), WithStateManager(manager))
+	estimate, err := EstimateGas(context, ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, &GasEstimate{GasUsed: defaultEstimateGasLimit, Reverted: true, Reason: errGasExceed.Error()}, estimate)
+
+	context = NewContext(WithGasLimit(1000), WithSender([]byte("sender")), WithContractAddress([]byte("contract")), WithStateManager(manager))
+	estimate, err = EstimateGas(context, ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, &GasEstimate{GasUsed: 1000, Reverted: true, Reason: errGasExceed.Error()}, estimate)
+
+	schedule := DefaultGasSchedule()
+	schedule.DeployPerByte = 1000
+	context = NewContext(WithGasLimit(1000), WithSender([]byte("creator")), WithStateManager(manager), WithGasSchedule(schedule))
+	estimate, err = EstimateDeployGas(context, deployCode(m.bytes(), nil), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &GasEstimate{GasUsed: 1000, Reverted: true, Reason: errGasExceed.Error()}, estimate)
+}
@@ -0,0 +1,104 @@
+package contract
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HostCost is the gas charged for a call of a host function,
+// PerByte is charged for each byte the host function reads or writes.
+// DirtyBase replaces Base when the call accesses a storage key which is
+// already changed in the contract state buffer.
+type HostCost struct {
+	Base      uint64
+	DirtyBase uint64
+	PerByte   uint64
+}
+
+// GasSchedule describes the gas costs of contract execution and deployment.
+// It implements compiler.GasPolicy for the wasm instructions.
+type GasSchedule struct {
+	// instruction costs by opcode class
+	Local      int64 // constants, local and global access, drop, select
+	Arith      int64 // other numeric instructions
+	Mul        int64 // multiplication, division and remainder
+	Memory     int64 // loads and stores
+	Control    int64 // branches and returns
+	Call       int64 // call and call_indirect
+	GrowMemory int64 // grow_memory
+
+	// deployment costs
+	DeployPerByte uint64
+	DeployPerKB   uint64
+
+	// host function cost overrides by field name, module.field for modules
+	// other than env, crypto and chain. They replace HostFunction.Cost.
+	HostFunctions map[string]HostCost
+}
+
+var defaultGasSchedule = DefaultGasSchedule()
+
+// DefaultGasSchedule returns a schedule which charges 1 for every instruction,
+// host functions are charged their HostFunction.Cost.
+func DefaultGasSchedule() *GasSchedule {
+	return &GasSchedule{
+		Local:         1,
+		Arith:         1,
+		Mul:           1,
+		Memory:        1,
+		Control:       1,
+		Call:          1,
+		GrowMemory:    1,
+		DeployPerKB:   gasByKBSize,
+		HostFunctions: map[string]HostCost{},
+	}
+}
+
+// GetCost returns the cost of the instruction op
+func (schedule *GasSchedule) GetCost(op string) int64 {
+	switch {
+	case op == "call" || op == "call_indirect":
+		return schedule.Call
+	case op == "grow_memory":
+		return schedule.GrowMemory
+	case strings.HasPrefix(op, "jmp") || strings.HasPrefix(op, "br") || op == "return":
+		return schedule.Control
+	case strings.Contains(op, ".load") || strings.Contains(op, ".store"):
+		return schedule.Memory
+	case strings.HasSuffix(op, ".mul") || strings.Contains(op, ".div") || strings.Contains(op, ".rem"):
+		return schedule.Mul
+	case strings.HasSuffix(op, ".const") || strings.HasSuffix(op, "_local") || strings.HasSuffix(op, "_global") ||
+		op == "drop" || op == "select" || op == "nop" || op == "phi":
+		return schedule.Local
+	default:
+		return schedule.Arith
+	}
+}
+
+// deployGas returns the gas charged for deploying code of size bytes
+func (schedule *GasSchedule) deployGas(size int) uint64 {
+	return uint64(size)*schedule.DeployPerByte + uint64(size/1024)*schedule.DeployPerKB
+}
+
+// gas returns the gas charged for a call handling size bytes
+func (cost HostCost) gas(size int, dirty bool) uint64 {
+	base := cost.Base
+	if dirty {
+		base = cost.DirtyBase
+	}
+	return base + uint64(size)*cost.PerByte
+}
+
+// policyKey identifies the instruction costs, modules compiled
+// with schedules of the same policyKey have the same gas counters.
+func (schedule *GasSchedule) policyKey() string {
+	return fmt.Sprintf("%d/%d/%d/%d/%d/%d/%d", schedule.Local, schedule.Arith, schedule.Mul,
+		schedule.Memory, schedule.Control, schedule.Call, schedule.GrowMemory)
+}
+
+func (context *Context) getGasSchedule() *GasSchedule {
+	if context.gasSchedule == nil {
+		return defaultGasSchedule
+	}
+	return context.gasSchedule
+}
@@ -0,0 +1,138 @@
+package contract
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+func TestGasScheduleCost(t *testing.T) {
+	schedule := &GasSchedule{Local: 1, Arith: 2, Mul: 3, Memory: 4, Control: 5, Call: 6, GrowMemory: 7}
+	assert.Equal(t, int64(1), schedule.GetCost("i32.const"))
+	assert.Equal(t, int64(1), schedule.GetCost("get_local"))
+	assert.Equal(t, int64(1), schedule.GetCost("set_global"))
+	assert.Equal(t, int64(2), schedule.GetCost("i32.add"))
+	assert.Equal(t, int64(3), schedule.GetCost("i64.div_u"))
+	assert.Equal(t, int64(3), schedule.GetCost("i32.rem_s"))
+	assert.Equal(t, int64(4), schedule.GetCost("i32.load8_u"))
+	assert.Equal(t, int64(4), schedule.GetCost("i64.store"))
+	assert.Equal(t, int64(5), schedule.GetCost("jmp_if"))
+	assert.Equal(t, int64(5), schedule.GetCost("return"))
+	assert.Equal(t, int64(6), schedule.GetCost("call_indirect"))
+	assert.Equal(t, int64(7), schedule.GetCost("grow_memory"))
+
+	deploy := &GasSchedule{DeployPerByte: 2, DeployPerKB: 10}
+	assert.Equal(t, uint64(2*2048+2*10), deploy.deployGas(2048))
+}
+
+func TestGasScheduleContext(t *testing.T) {
+	m := newTestModule()
+	m.entry("invoke", i64Const(1), i64Const(2), []byte{opI64Add, opDrop}, i64Const(3))
+
+	store := createDB(t)
+	defer closeDB(t, store)
+	crtState, _ := createContractState(t, store)
+
+	context := &Context{gasLimit: 10000}
+	_, err := Create(crtState, context, deployCode(m.bytes(), nil))
+	assert.NoError(t, err)
+	receipt, err := call(m.bytes(), &types.CallInfo{Name: "invoke"}, newExternalResolver(context, crtState))
+	assert.NoError(t, err)
+
+	schedule := DefaultGasSchedule()
+	schedule.Arith = 11
+	expensive := &Context{gasLimit: 10000, gasSchedule: schedule}
+	costly, err := call(m.bytes(), &types.CallInfo{Name: "invoke"}, newExternalResolver(expensive, crtState))
+	assert.NoError(t, err)
+	assert.Equal(t, receipt.GasUsed+10, costly.GasUsed)
+
+	schedule = DefaultGasSchedule()
+	schedule.DeployPerByte = 100
+	_, err = Create(crtState, &Context{gasLimit: 100, gasSchedule: schedule}, deployCode(m.bytes(), nil))
+	assert.Equal(t, errGasExceed, err)
+}
+
+func TestDeployGasTrailingBytes(t *testing.T) {
+	m := newTestModule()
+	m.entry("invoke", i64Const(1))
+	module := m.bytes()
+
+	store := createDB(t)
+	defer closeDB(t, store)
+	crtState, _ := createContractState(t, store)
+
+	// the bytes after the module are stored with it and are charged as well
+	schedule := &GasSchedule{DeployPerByte: 1}
+	trailing := make([]byte, 100)
+	code := deployCode(append(append([]byte{}, module...), trailing...), nil)
+	binary.LittleEndian.PutUint32(code[4:], uint32(len(module)))
+	receipt, err := Create(crtState, &Context{gasLimit: 10000, gasSchedule: schedule}, code)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4+len(module)+len(trailing)), receipt.GasUsed)
+	stored, err := crtState.GetCode()
+	assert.NoError(t, err)
+	assert.Equal(t, 4+len(module)+len(trailing), len(stored))
+}
+
+func TestZeroGasLimit(t *testing.T) {
+	m := newTestModule()
+	m.entry("invoke", i64Const(1))
+
+	store := createDB(t)
+	defer closeDB(t, store)
+	crtState, _ := createContractState(t, store)
+
+	initCall, _ := proto.Marshal(&types.CallInfo{Name: "invoke"})
+	_, err := Create(crtState, NewContext(), deployCode(m.bytes(), initCall))
+	assert.Equal(t, errGasExceed, err)
+	receipt, err := Create(crtState, NewContext(), deployCode(m.bytes(), nil))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), receipt.GasUsed)
+
+	receipt, err = Call(crtState, NewContext(), initCall)
+	assert.Equal(t, errGasExceed, err)
+	assert.Equal(t, uint64(0), receipt.GasUsed)
+}
+
+func TestHostGas(t *testing.T) {
+	m := newTestModule()
+	set := m.importFunc("_set", []byte{i32, i32, i32, i32}, []byte{i32})
+	m.putData(0, []byte("keyvalue"))
+	storeKey := concat(i32Const(0), i32Const(3), i32Const(3), i32Const(5), callFunc(set), []byte{opDrop})
+	m.entry("once", storeKey, i64Const(1))
+	m.entry("twice", storeKey, storeKey, i64Const(1))
+
+	store := createDB(t)
+	defer closeDB(t, store)
+	crtState, _ := createContractState(t, store)
+
+	free := DefaultGasSchedule()
+	free.HostFunctions["_set"] = HostCost{}
+	run := func(context *Context, name string) uint64 {
+		receipt, err := call(m.bytes(), &types.CallInfo{Name: name}, newExternalResolver(context, crtState))
+		assert.NoError(t, err)
+		crtState.Rollback(0)
+		return receipt.GasUsed
+	}
+
+	context := &Context{gasLimit: 10000}
+	freeContext := &Context{gasLimit: 10000, gasSchedule: free}
+	assert.Equal(t, run(freeContext, "once")+20+8*2, run(context, "once"))
+	// the second write hits a dirty key
+	assert.Equal(t, run(freeContext, "twice")+20+5+2*8*2, run(context, "twice"))
+	// schedules without host function entries charge the costs of the host functions
+	handmade := &GasSchedule{Local: 1, Arith: 1, Mul: 1, Memory: 1, Control: 1, Call: 1, GrowMemory: 1}
+	assert.Equal(t, run(context, "once"), run(&Context{gasLimit: 10000, gasSchedule: handmade}, "once"))
+
+	expensive := DefaultGasSchedule()
+	expensive.HostFunctions["_set"] = HostCost{Base: 10000}
+	context = &Context{gasLimit: 10000, gasSchedule: expensive}
+	receipt, err := call(m.bytes(), &types.CallInfo{Name: "once"}, newExternalResolver(context, crtState))
+	assert.Equal(t, errGasExceed, err)
+	assert.Equal(t, context.gasLimit, receipt.GasUsed)
+	val, _ := crtState.GetData([]byte("key"))
+	assert.Nil(t, val)
+}
@@ -0,0 +1,283 @@
+package contract
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/go-interpreter/wagon/wasm"
+	"github.com/perlin-network/life/exec"
+	"github.com/zhigui-projects/zwasm/state"
+)
+
+// names of the built-in host modules
+const (
+	EnvModule    = "env"
+	CryptoModule = "crypto"
+	ChainModule  = "chain"
+)
+
+var errHostModuleExists = errors.New("host module is already registered")
+
+// ValueType is the wasm type of a host function parameter or result
+type ValueType byte
+
+// value types host functions can use by their wasm encoding, floats are not allowed in contracts
+const (
+	I32 ValueType = 0x7f
+	I64 ValueType = 0x7e
+)
+
+// HostFunc implements a host function, an error traps the calling contract.
+type HostFunc func(call *HostCall) (int64, error)
+
+// HostFunction is a function a host module provides to contracts.
+// Imports must declare Params, Results may be left out to drop the result.
+// Cost is charged by HostCall.UseGas unless the GasSchedule has an entry for the function,
+// a function which Writes state traps in read-only calls.
+type HostFunction struct {
+	Params  []ValueType
+	Results []ValueType
+	Cost    HostCost
+	Writes  bool
+	Func    HostFunc
+}
+
+// HostModule provides host functions contracts import from the module Name
+type HostModule interface {
+	Name() string
+	// Function returns nil when the module does not provide field
+	Function(field string) *HostFunction
+}
+
+type hostModule struct {
+	name      string
+	functions map[string]*HostFunction
+}
+
+// NewHostModule returns a HostModule name providing functions by field name
+func NewHostModule(name string, functions map[string]*HostFunction) HostModule {
+	return &hostModule{name: name, functions: functions}
+}
+
+func (m *hostModule) Name() string {
+	return m.name
+}
+
+func (m *hostModule) Function(field string) *HostFunction {
+	return m.functions[field]
+}
+
+type fallbackModule struct {
+	HostModule
+	fallbacks []HostModule
+}
+
+// withFallback returns module providing the functions of fallbacks which module lacks
+func withFallback(module HostModule, fallbacks ...HostModule) HostModule {
+	return &fallbackModule{HostModule: module, fallbacks: fallbacks}
+}
+
+func (m *fallbackModule) Function(field string) *HostFunction {
+	if fn := m.HostModule.Function(field); fn != nil {
+		return fn
+	}
+	for _, fallback := range m.fallbacks {
+		if fn := fallback.Function(field); fn != nil {
+			return fn
+		}
+	}
+	return nil
+}
+
+// HostRegistry holds the host modules contracts can import functions from
+type HostRegistry struct {
+	lock    sync.RWMutex
+	modules map[string]HostModule
+}
+
+var defaultHostRegistry = NewHostRegistry(BuiltinHostModules()...)
+
+// NewHostRegistry returns a registry of modules, a module replaces an earlier one of the same name
+func NewHostRegistry(modules ...HostModule) *HostRegistry {
+	registry := &HostRegistry{modules: make(map[string]HostModule)}
+	for _, m := range modules {
+		registry.modules[m.Name()] = m
+	}
+	return registry
+}
+
+// BuiltinHostModules returns the env, crypto and chain modules. The env module also
+// provides the crypto and chain functions, contracts imported all host functions
+// from env before the modules were split.
+func BuiltinHostModules() []HostModule {
+	return []HostModule{
+		withFallback(envHostModule, cryptoHostModule, chainHostModule),
+		cryptoHostModule,
+		chainHostModule,
+	}
+}
+
+// DefaultHostRegistry returns the registry of the built-in modules used by executions without WithHostRegistry
+func DefaultHostRegistry() *HostRegistry {
+	return defaultHostRegistry
+}
+
+// Register adds module to the registry, module names are unique
+func (registry *HostRegistry) Register(module HostModule) error {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	if _, ok := registry.modules[module.Name()]; ok {
+		return errHostModuleExists
+	}
+	registry.modules[module.Name()] = module
+	return nil
+}
+
+// Module returns the module registered as name
+func (registry *HostRegistry) Module(name string) (HostModule, bool) {
+	registry.lock.RLock()
+	defer registry.lock.RUnlock()
+	m, ok := registry.modules[name]
+	return m, ok
+}
+
+// lookup returns the host function field of module and the name of its gas cost
+func (registry *HostRegistry) lookup(module, field string) (*HostFunction, string) {
+	if m, ok := registry.Module(module); ok {
+		if fn := m.Function(field); fn != nil {
+			return fn, hostGasName(module, field)
+		}
+	}
+	return nil, ""
+}
+
+// hostGasName returns the GasSchedule key of a host function, the built-in
+// functions are keyed by field and the others by module.field.
+func hostGasName(module, field string) string {
+	switch module {
+	case EnvModule, CryptoModule, ChainModule:
+		return field
+	default:
+		return module + "." + field
+	}
+}
+
+// matchSignature tells whether an import of fn has the function type sig
+func (fn *HostFunction) matchSignature(sig wasm.FunctionSig) bool {
+	if !matchTypes(fn.Params, sig.ParamTypes) {
+		return false
+	}
+	return len(sig.ReturnTypes) == 0 || matchTypes(fn.Results, sig.ReturnTypes)
+}
+
+func matchTypes(expected []ValueType, types []wasm.ValueType) bool {
+	if len(expected) != len(types) {
+		return false
+	}
+	for i, t := range types {
+		if expected[i] != valueType(t) {
+			return false
+		}
+	}
+	return true
+}
+
+func valueType(t wasm.ValueType) ValueType {
+	switch t {
+	case wasm.ValueTypeI32:
+		return I32
+	case wasm.ValueTypeI64:
+		return I64
+	default:
+		return 0
+	}
+}
+
+// HostCall is a call of a host function by a contract
+type HostCall struct {
+	vm       *exec.VirtualMachine
+	resolver *externalResolver
+	cost     HostCost
+}
+
+// hostFunc returns the import of fn for vm, name is its GasSchedule key
+func (shim *externalResolver) hostFunc(name string, fn *HostFunction) exec.FunctionImport {
+	cost, ok := shim.context.getGasSchedule().HostFunctions[name]
+	if !ok {
+		cost = fn.Cost
+	}
+	return func(vm *exec.VirtualMachine) int64 {
+		if fn.Writes {
+			if err := shim.checkWritable(); err != nil {
+				return trap(vm, err)
+			}
+		}
+		ret, err := fn.Func(&HostCall{vm: vm, resolver: shim, cost: cost})
+		if err != nil {
+			return trap(vm, err)
+		}
+		return ret
+	}
+}
+
+// Arg returns the argument i of the call
+func (call *HostCall) Arg(i int) int64 {
+	return call.vm.GetCurrentFrame().Locals[i]
+}
+
+// ptrArg returns the argument i of the call as a memory offset or length
+func (call *HostCall) ptrArg(i int) int {
+	return int(uint32(call.Arg(i)))
+}
+
+// Read returns a copy of the length bytes of the contract memory at ptr
+func (call *HostCall) Read(ptr uint32, length uint32) ([]byte, error) {
+	return readMemory(call.vm, int(ptr), int(length))
+}
+
+// Write copies data to the contract memory at ptr
+func (call *HostCall) Write(ptr uint32, data []byte) error {
+	return writeMemory(call.vm, int(ptr), data)
+}
+
+// UseGas charges the cost of the host function for handling size bytes
+func (call *HostCall) UseGas(size int) error {
+	return call.useGas(size, false)
+}
+
+// useGas charges the cost of the host function for handling size bytes, dirty tells
+// the call accesses a key already changed in the contract state buffer.
+func (call *HostCall) useGas(size int, dirty bool) error {
+	if !call.resolver.useGas(call.vm, call.cost.gas(size, dirty)) {
+		return errGasExceed
+	}
+	return nil
+}
+
+// State returns the state of the called contract
+func (call *HostCall) State() *state.ContractState {
+	return call.resolver.crtState
+}
+
+// Sender returns the address of the caller
+func (call *HostCall) Sender() []byte {
+	return call.resolver.context.senderAddress
+}
+
+// Address returns the address of the called contract
+func (call *HostCall) Address() []byte {
+	return call.resolver.context.contractAddress
+}
+
+// ReadOnly tells whether state changes are forbidden
+func (call *HostCall) ReadOnly() bool {
+	return call.resolver.context.readOnly
+}
+
+// getHostRegistry returns the registry of the context or the default registry
+func (context *Context) getHostRegistry() *HostRegistry {
+	if context.hostRegistry == nil {
+		return defaultHostRegistry
+	}
+	return context.hostRegistry
+}
@@ -0,0 +1,131 @@
+package contract
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+// appModule is an application host module with a pure and a writing function
+func appModule() HostModule {
+	return NewHostModule("app", map[string]*HostFunction{
+		"double": {
+			Params:  []ValueType{I64},
+			Results: []ValueType{I64},
+			Cost:    HostCost{Base: 7},
+			Func: func(call *HostCall) (int64, error) {
+				if err := call.UseGas(0); err != nil {
+					return 0, err
+				}
+				return call.Arg(0) * 2, nil
+			},
+		},
+		"store_sender": {
+			Results: []ValueType{I32},
+			Writes:  true,
+			Func: func(call *HostCall) (int64, error) {
+				return 1, call.State().SetData([]byte("sender"), call.Sender())
+			},
+		},
+	})
+}
+
+func appCallerModule() []byte {
+	m := newTestModule()
+	double := m.importFrom("app", "double", []byte{i64}, []byte{i64})
+	storeSender := m.importFrom("app", "store_sender", nil, []byte{i32})
+	sha3 := m.importFrom("crypto", "_sha3_256", []byte{i32, i32, i32}, []byte{i32})
+	m.putData(0, []byte("abc"))
+	m.entry("double", i64Const(21), callFunc(double))
+	m.entry("store_sender", callFunc(storeSender), []byte{opI64ExtendU})
+	m.entry("hash", i32Const(0), i32Const(3), i32Const(16), callFunc(sha3), []byte{opI64ExtendU})
+	return m.bytes()
+}
+
+func TestHostRegistry(t *testing.T) {
+	registry := NewHostRegistry(BuiltinHostModules()...)
+	assert.NoError(t, registry.Register(appModule()))
+	assert.Equal(t, errHostModuleExists, registry.Register(appModule()))
+	_, ok := registry.Module("app")
+	assert.True(t, ok)
+
+	fn, name := registry.lookup("app", "double")
+	assert.NotNil(t, fn)
+	assert.Equal(t, "app.double", name)
+	fn, name = registry.lookup("env", "_sha3_256")
+	assert.NotNil(t, fn)
+	assert.Equal(t, "_sha3_256", name)
+	fn, _ = registry.lookup("env", "double")
+	assert.Nil(t, fn)
+	fn, _ = DefaultHostRegistry().lookup("app", "double")
+	assert.Nil(t, fn)
+
+	// only the modules of the registry resolve
+	registry = NewHostRegistry(envHostModule, appModule())
+	fn, _ = registry.lookup("env", "_set")
+	assert.NotNil(t, fn)
+	fn, _ = registry.lookup("env", "_sha3_256")
+	assert.Nil(t, fn)
+	fn, _ = registry.lookup("crypto", "_sha3_256")
+	assert.Nil(t, fn)
+	registry = NewHostRegistry(append(BuiltinHostModules(), NewHostModule(EnvModule, nil))...)
+	fn, _ = registry.lookup("env", "_set")
+	assert.Nil(t, fn)
+}
+
+func TestHostModuleCall(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	crtState, _ := createContractState(t, store)
+	registry := NewHostRegistry(BuiltinHostModules()...)
+	assert.NoError(t, registry.Register(appModule()))
+
+	_, err := Create(crtState, NewContext(WithGasLimit(10000)), deployCode(appCallerModule(), nil))
+	assert.Equal(t, &ImportError{Module: "app", Field: "double", Kind: "function"}, err)
+
+	context := NewContext(WithGasLimit(10000), WithSender([]byte("sender")), WithHostRegistry(registry))
+	_, err = Create(crtState, context, deployCode(appCallerModule(), nil))
+	assert.NoError(t, err)
+
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "double"})
+	receipt, err := Call(crtState, context, ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), receipt.Ret)
+
+	schedule := DefaultGasSchedule()
+	schedule.HostFunctions["app.double"] = HostCost{Base: 10}
+	expensive, err := Call(crtState, NewContext(WithGasLimit(10000), WithHostRegistry(registry), WithGasSchedule(schedule)), ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, receipt.GasUsed+3, expensive.GasUsed)
+
+	ciBuf, _ = proto.Marshal(&types.CallInfo{Name: "hash"})
+	receipt, err = Call(crtState, context, ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(hashLength), receipt.Ret)
+
+	ciBuf, _ = proto.Marshal(&types.CallInfo{Name: "store_sender"})
+	_, err = Query(crtState, context, ciBuf)
+	assert.Equal(t, errReadOnly, err)
+	receipt, err = Call(crtState, context, ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), receipt.Ret)
+	val, err := crtState.GetData([]byte("sender"))
+	assert.NoError(t, err)
+	assert.Equal(t, "sender", string(val))
+}
+
+func TestHostFunctionSignature(t *testing.T) {
+	m := newTestModule()
+	set := m.importFunc("_set", []byte{i32, i32, i32}, []byte{i32})
+	m.entry("invoke", i32Const(0), i32Const(0), i32Const(0), callFunc(set), []byte{opI64ExtendU})
+	resolver := newExternalResolver(&Context{}, nil)
+	assert.EqualError(t, validateCode(m.bytes(), resolver, ""), "invalid module: import env._set does not match the host function signature")
+
+	// the result of a host function may be dropped
+	m = newTestModule()
+	set = m.importFunc("_set", []byte{i32, i32, i32, i32}, nil)
+	m.entry("invoke", i32Const(0), i32Const(0), i32Const(0), i32Const(0), callFunc(set), i64Const(1))
+	assert.NoError(t, validateCode(m.bytes(), resolver, ""))
+}
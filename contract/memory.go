@@ -0,0 +1,37 @@
+package contract
+
+import (
+	"github.com/perlin-network/life/exec"
+)
+
+// memoryRange returns the length bytes of the memory of vm at ptr without copying them,
+// the error is a *MemoryAccessError when they are not inside the memory.
+func memoryRange(vm *exec.VirtualMachine, ptr int, length int) ([]byte, error) {
+	end := uint64(ptr) + uint64(length)
+	if ptr < 0 || length < 0 || end > uint64(len(vm.Memory)) {
+		return nil, &MemoryAccessError{Offset: uint64(ptr), Length: uint64(length), Size: len(vm.Memory)}
+	}
+	return vm.Memory[ptr:end:end], nil
+}
+
+// readMemory returns a copy of the length bytes of the memory of vm at ptr
+func readMemory(vm *exec.VirtualMachine, ptr int, length int) ([]byte, error) {
+	mem, err := memoryRange(vm, ptr, length)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, length)
+	copy(data, mem)
+	return data, nil
+}
+
+// writeMemory copies data to the memory of vm at ptr, nothing is written when
+// data does not fit into the memory.
+func writeMemory(vm *exec.VirtualMachine, ptr int, data []byte) error {
+	mem, err := memoryRange(vm, ptr, len(data))
+	if err != nil {
+		return err
+	}
+	copy(mem, data)
+	return nil
+}
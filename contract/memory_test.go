@@ -0,0 +1,94 @@
+package contract
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/perlin-network/life/exec"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+func TestMemoryRange(t *testing.T) {
+	vm := &exec.VirtualMachine{Memory: []byte("0123456789")}
+
+	mem, err := memoryRange(vm, 2, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, "234", string(mem))
+	assert.Equal(t, 3, cap(mem))
+	mem, err = memoryRange(vm, 10, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, mem)
+
+	_, err = memoryRange(vm, 8, 3)
+	assert.Equal(t, &MemoryAccessError{Offset: 8, Length: 3, Size: 10}, err)
+	_, err = memoryRange(vm, int(^uint32(0)), int(^uint32(0)))
+	assert.IsType(t, &MemoryAccessError{}, err)
+
+	data, err := readMemory(vm, 0, 2)
+	assert.NoError(t, err)
+	data[0] = 'x'
+	assert.Equal(t, byte('0'), vm.Memory[0])
+
+	assert.NoError(t, writeMemory(vm, 8, []byte("ab")))
+	assert.Equal(t, "01234567ab", string(vm.Memory))
+	assert.IsType(t, &MemoryAccessError{}, writeMemory(vm, 9, []byte("cd")))
+	assert.Equal(t, "01234567ab", string(vm.Memory))
+}
+
+func memoryModule() []byte {
+	m := newTestModule()
+	set := m.importFunc("_set", []byte{i32, i32, i32, i32}, []byte{i32})
+	getBuf := m.importFunc("_get_buf", []byte{i32, i32, i32, i32}, []byte{i64})
+	setReturn := m.importFunc("_set_return", []byte{i32, i32}, []byte{i32})
+	sha3 := m.importFunc("_sha3_256", []byte{i32, i32, i32}, []byte{i32})
+	m.putData(0, []byte("keyvalue"))
+	storeKey := concat(i32Const(0), i32Const(3), i32Const(3), i32Const(5), callFunc(set), []byte{opDrop})
+	m.entry("get_truncated", storeKey, i32Const(0), i32Const(3), i32Const(100), i32Const(2), callFunc(getBuf),
+		i32Const(100), i32Const(2), callFunc(setReturn), []byte{opDrop})
+	m.entry("get_full", storeKey, i32Const(0), i32Const(3), i32Const(100), i32Const(16), callFunc(getBuf),
+		i32Const(100), i32Const(5), callFunc(setReturn), []byte{opDrop})
+	m.entry("read_out_of_bounds", i32Const(-16), i32Const(32), i32Const(3), i32Const(5), callFunc(set), []byte{opI64ExtendU})
+	m.entry("write_out_of_bounds", i32Const(0), i32Const(8), i32Const(-16), callFunc(sha3), []byte{opI64ExtendU})
+	m.entry("get_out_of_bounds", i32Const(0), i32Const(3), i32Const(-16), i32Const(32), callFunc(getBuf))
+	return m.bytes()
+}
+
+func TestHostMemoryBounds(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	crtState, _ := createContractState(t, store)
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender")}
+	_, err := Create(crtState, context, deployCode(memoryModule(), nil))
+	assert.NoError(t, err)
+
+	for _, fn := range []string{"read_out_of_bounds", "write_out_of_bounds", "get_out_of_bounds"} {
+		ciBuf, _ := proto.Marshal(&types.CallInfo{Name: fn})
+		receipt, err := Call(crtState, context, ciBuf)
+		assert.IsType(t, &MemoryAccessError{}, err, fn)
+		assert.Equal(t, ReceiptFailed, receipt.Status, fn)
+	}
+	val, _ := crtState.GetData([]byte("key"))
+	assert.Nil(t, val)
+}
+
+func TestGetBuf(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	crtState, _ := createContractState(t, store)
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender")}
+	_, err := Create(crtState, context, deployCode(memoryModule(), nil))
+	assert.NoError(t, err)
+
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "get_truncated"})
+	receipt, err := Call(crtState, context, ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), receipt.Ret)
+	assert.Equal(t, "va", string(receipt.ReturnData))
+
+	ciBuf, _ = proto.Marshal(&types.CallInfo{Name: "get_full"})
+	receipt, err = Call(crtState, context, ciBuf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), receipt.Ret)
+	assert.Equal(t, "value", string(receipt.ReturnData))
+}
@@ -0,0 +1,260 @@
+package contract
+
+import (
+	"encoding/binary"
+)
+
+// value types and opcodes used by the test modules.
+const (
+	i32 = 0x7f
+	i64 = 0x7e
+	f32 = 0x7d
+
+	opUnreachable = 0x00
+	opLoop        = 0x03
+	opEnd         = 0x0b
+	opBr          = 0x0c
+	opCall        = 0x10
+	opDrop        = 0x1a
+	opGetLocal    = 0x20
+	opI32Load     = 0x28
+	opI32Store    = 0x36
+	opI32Const    = 0x41
+	opI64Const    = 0x42
+	opF32Const    = 0x43
+	opI64Add      = 0x7c
+	opI64ExtendS  = 0xac
+	opI64ExtendU  = 0xad
+
+	blockVoid = 0x40
+)
+
+type testImport struct {
+	module  string
+	field   string
+	params  []byte
+	results []byte
+}
+
+type testFunc struct {
+	name    string
+	params  []byte
+	results []byte
+	locals  []byte
+	body    []byte
+}
+
+type testData struct {
+	offset uint32
+	data   []byte
+}
+
+type testCustom struct {
+	name string
+	data []byte
+}
+
+// testModule assembles a minimal wasm binary for host function tests.
+type testModule struct {
+	imports []testImport
+	funcs   []testFunc
+	data    []testData
+	customs []testCustom
+	pages   uint32
+	start   int
+}
+
+func newTestModule() *testModule {
+	return &testModule{pages: 1, start: -1}
+}
+
+func (m *testModule) importFunc(field string, params []byte, results []byte) int {
+	return m.importFrom("env", field, params, results)
+}
+
+func (m *testModule) importFrom(module string, field string, params []byte, results []byte) int {
+	m.imports = append(m.imports, testImport{module: module, field: field, params: params, results: results})
+	return len(m.imports) - 1
+}
+
+// entry adds an exported function with the (ptr, len) signature call uses.
+func (m *testModule) entry(name string, body ...[]byte) int {
+	return m.function(name, []byte{i32, i32}, []byte{i64}, body...)
+}
+
+// function adds a function, it is not exported when name is empty.
+func (m *testModule) function(name string, params []byte, results []byte, body ...[]byte) int {
+	m.funcs = append(m.funcs, testFunc{name: name, params: params, results: results, body: concat(body...)})
+	return len(m.imports) + len(m.funcs) - 1
+}
+
+func (m *testModule) putData(offset uint32, data []byte) {
+	m.data = append(m.data, testData{offset: offset, data: data})
+}
+
+func (m *testModule) custom(name string, data []byte) {
+	m.customs = append(m.customs, testCustom{name: name, data: data})
+}
+
+func (m *testModule) bytes() []byte {
+	out := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+	var types []byte
+	for _, imp := range m.imports {
+		types = append(types, funcType(imp.params, imp.results)...)
+	}
+	for _, f := range m.funcs {
+		types = append(types, funcType(f.params, f.results)...)
+	}
+	out = append(out, section(1, vector(len(m.imports)+len(m.funcs), types))...)
+
+	if len(m.imports) > 0 {
+		var imports []byte
+		for i, imp := range m.imports {
+			imports = append(imports, name(imp.module)...)
+			imports = append(imports, name(imp.field)...)
+			imports = append(imports, 0x00)
+			imports = append(imports, uleb(uint64(i))...)
+		}
+		out = append(out, section(2, vector(len(m.imports), imports))...)
+	}
+
+	var funcs []byte
+	for i := range m.funcs {
+		funcs = append(funcs, uleb(uint64(len(m.imports)+i))...)
+	}
+	out = append(out, section(3, vector(len(m.funcs), funcs))...)
+
+	out = append(out, section(5, vector(1, concat([]byte{0x00}, uleb(uint64(m.pages)))))...)
+
+	var exports []byte
+	count := 1
+	for i, f := range m.funcs {
+		if f.name == "" {
+			continue
+		}
+		exports = append(exports, name(f.name)...)
+		exports = append(exports, 0x00)
+		exports = append(exports, uleb(uint64(len(m.imports)+i))...)
+		count++
+	}
+	exports = append(exports, name("memory")...)
+	exports = append(exports, 0x02, 0x00)
+	out = append(out, section(7, vector(count, exports))...)
+
+	if m.start >= 0 {
+		out = append(out, section(8, uleb(uint64(m.start)))...)
+	}
+
+	var codes []byte
+	for _, f := range m.funcs {
+		var locals []byte
+		for _, l := range f.locals {
+			locals = append(locals, 0x01, l)
+		}
+		body := concat(vector(len(f.locals), locals), f.body, []byte{opEnd})
+		codes = append(codes, uleb(uint64(len(body)))...)
+		codes = append(codes, body...)
+	}
+	out = append(out, section(10, vector(len(m.funcs), codes))...)
+
+	if len(m.data) > 0 {
+		var data []byte
+		for _, d := range m.data {
+			data = append(data, 0x00)
+			data = append(data, i32Const(int32(d.offset))...)
+			data = append(data, opEnd)
+			data = append(data, uleb(uint64(len(d.data)))...)
+			data = append(data, d.data...)
+		}
+		out = append(out, section(11, vector(len(m.data), data))...)
+	}
+
+	for _, c := range m.customs {
+		out = append(out, section(0, concat(name(c.name), c.data))...)
+	}
+	return out
+}
+
+// deployCode wraps a module into the length prefixed format setCode expects.
+func deployCode(module []byte, initCall []byte) []byte {
+	inner := make([]byte, 4)
+	binary.LittleEndian.PutUint32(inner, uint32(len(module)))
+	inner = append(inner, module...)
+
+	total := make([]byte, 4)
+	binary.LittleEndian.PutUint32(total, uint32(4+len(inner)))
+	total = append(total, inner...)
+	return append(total, initCall...)
+}
+
+func funcType(params []byte, results []byte) []byte {
+	return concat([]byte{0x60}, vector(len(params), params), vector(len(results), results))
+}
+
+func section(id byte, payload []byte) []byte {
+	return concat([]byte{id}, uleb(uint64(len(payload))), payload)
+}
+
+func vector(n int, payload []byte) []byte {
+	return concat(uleb(uint64(n)), payload)
+}
+
+func name(s string) []byte {
+	return concat(uleb(uint64(len(s))), []byte(s))
+}
+
+func concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func uleb(v uint64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			out = append(out, b|0x80)
+			continue
+		}
+		return append(out, b)
+	}
+}
+
+func sleb(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func i32Const(v int32) []byte {
+	return concat([]byte{opI32Const}, sleb(int64(v)))
+}
+
+func i64Const(v int64) []byte {
+	return concat([]byte{opI64Const}, sleb(v))
+}
+
+func callFunc(idx int) []byte {
+	return concat([]byte{opCall}, uleb(uint64(idx)))
+}
+
+func getLocal(idx int) []byte {
+	return concat([]byte{opGetLocal}, uleb(uint64(idx)))
+}
+
+// infiniteLoop never returns, the execution ends up running out of gas.
+// the loop body must not be empty, life charges no gas for a bare branch.
+func infiniteLoop() []byte {
+	return concat([]byte{opLoop, blockVoid}, i32Const(0), []byte{opDrop, opBr, 0x00, opEnd})
+}
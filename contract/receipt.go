@@ -0,0 +1,55 @@
+package contract
+
+// ReceiptStatus is the result status of a contract execution
+type ReceiptStatus int
+
+const (
+	ReceiptSuccess ReceiptStatus = iota
+	ReceiptFailed
+)
+
+func (status ReceiptStatus) String() string {
+	switch status {
+	case ReceiptSuccess:
+		return "SUCCESS"
+	case ReceiptFailed:
+		return "FAILED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Event is emitted by a contract through _emit_event
+type Event struct {
+	Name string `json:"name"`
+	Data []byte `json:"data"`
+}
+
+// Receipt is the result of Create or Call.
+// Destructed lists the addresses of contracts removed by _self_destruct,
+// their states must not be put back into the state manager.
+type Receipt struct {
+	Status     ReceiptStatus `json:"status"`
+	GasUsed    uint64        `json:"gasUsed"`
+	Ret        int64         `json:"ret"`
+	ReturnData []byte        `json:"returnData,omitempty"`
+	Events     []*Event      `json:"events"`
+	Destructed [][]byte      `json:"destructed,omitempty"`
+}
+
+func newReceipt(ret int64, gasUsed uint64, resolver *externalResolver, err error) *Receipt {
+	receipt := &Receipt{
+		Status:  ReceiptSuccess,
+		GasUsed: gasUsed,
+		Ret:     ret,
+	}
+	if err != nil {
+		receipt.Status = ReceiptFailed
+		return receipt
+	}
+	if resolver != nil {
+		receipt.ReturnData = resolver.returnData
+		receipt.Events = resolver.events
+	}
+	return receipt
+}
@@ -0,0 +1,37 @@
+package contract
+
+import (
+	"errors"
+
+	"github.com/go-interpreter/wagon/wasm"
+	"github.com/perlin-network/life/exec"
+)
+
+var errStartWrite = errors.New("state modification is not allowed in start function")
+
+// hasStartFunction tells whether m has a start section
+func hasStartFunction(m *wasm.Module) bool {
+	return m != nil && m.Start != nil
+}
+
+// runStart runs the start function of the module of vm. It is metered like any other
+// function and may only change state when the context allows it with WithStartWrites.
+func (shim *externalResolver) runStart(vm *exec.VirtualMachine) error {
+	shim.starting = true
+	defer func() {
+		shim.starting = false
+	}()
+	_, err := vm.Run(int(vm.Module.Base.Start.Index))
+	return err
+}
+
+// checkWritable returns the error of a state modification which is not allowed
+func (shim *externalResolver) checkWritable() error {
+	if shim.context.readOnly {
+		return errReadOnly
+	}
+	if shim.starting && !shim.context.startWrites {
+		return errStartWrite
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package contract
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+// startModule returns a module whose start function stores 7 at offset 100 of its memory,
+// writes "key" when write is set and never returns when loop is set. read returns the stored value.
+func startModule(write bool, loop bool) []byte {
+	m := newTestModule()
+	set := m.importFunc("_set", []byte{i32, i32, i32, i32}, []byte{i32})
+	m.putData(0, []byte("keyvalue"))
+	body := concat(i32Const(100), i32Const(7), []byte{opI32Store, 0x02, 0x00})
+	if write {
+		body = concat(body, i32Const(0), i32Const(3), i32Const(3), i32Const(5), callFunc(set), []byte{opDrop})
+	}
+	if loop {
+		body = concat(body, infiniteLoop())
+	}
+	m.start = m.function("", nil, nil, body)
+	m.entry("read", i32Const(100), []byte{opI32Load, 0x02, 0x00, opI64ExtendU})
+	return m.bytes()
+}
+
+func TestCreateStart(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	crtState, _ := createContractState(t, store)
+
+	context := NewContext(WithGasLimit(10000), WithSender([]byte("sender")))
+	initCall, _ := proto.Marshal(&types.CallInfo{Name: "read"})
+	receipt, err := Create(crtState, context, deployCode(startModule(false, false), initCall))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), receipt.Ret)
+
+	// the start function only runs on deploy
+	receipt, err = Call(crtState, context, initCall)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), receipt.Ret)
+}
+
+func TestCreateStartGas(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	crtState, _ := createContractState(t, store)
+
+	context := NewContext(WithGasLimit(10000), WithSender([]byte("sender")))
+	receipt, err := Create(crtState, context, deployCode(startModule(false, false), nil))
+	assert.NoError(t, err)
+	assert.True(t, receipt.GasUsed > 0)
+
+	crtState, _ = createContractState(t, store)
+	receipt, err = Create(crtState, context, deployCode(startModule(false, true), nil))
+	assert.Equal(t, errGasExceed, err)
+	assert.Equal(t, context.gasLimit, receipt.GasUsed)
+	assert.Nil(t, crtState.CodeHash)
+}
+
+func TestCreateStartWrite(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	crtState, _ := createContractState(t, store)
+
+	_, err := Create(crtState, NewContext(WithGasLimit(10000), WithSender([]byte("sender"))), deployCode(startModule(true, false), nil))
+	assert.Equal(t, errStartWrite, err)
+	val, _ := crtState.GetData([]byte("key"))
+	assert.Nil(t, val)
+
+	context := NewContext(WithGasLimit(10000), WithSender([]byte("sender")), WithStartWrites(true))
+	_, err = Create(crtState, context, deployCode(startModule(true, false), nil))
+	assert.NoError(t, err)
+	val, _ = crtState.GetData([]byte("key"))
+	assert.Equal(t, "value", string(val))
+}
+
+func TestUpgradeStart(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	crtState, _ := createContractState(t, store)
+
+	context := NewContext(WithGasLimit(10000), WithSender([]byte("creator")))
+	_, err := Create(crtState, context, deployCode(versionModule(1, false, false), nil))
+	assert.NoError(t, err)
+	_, err = Upgrade(crtState, context, deployCode(startModule(false, false), nil))
+	assert.Equal(t, errStartFunc, err)
+	assert.Equal(t, int64(1), callVersion(t, crtState))
+}
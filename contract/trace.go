@@ -0,0 +1,172 @@
+package contract
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/perlin-network/life/exec"
+)
+
+// storage operations of a StorageTrace
+const (
+	StorageRead   = "read"
+	StorageWrite  = "write"
+	StorageDelete = "delete"
+)
+
+// HostCallTrace records a call of a host function
+type HostCallTrace struct {
+	Contract  []byte  `json:"contract"`
+	Depth     int     `json:"depth"`
+	Name      string  `json:"name"`
+	Args      []int64 `json:"args"`
+	Result    int64   `json:"result"`
+	GasBefore uint64  `json:"gasBefore"`
+	GasAfter  uint64  `json:"gasAfter"`
+}
+
+// StorageTrace records a storage access, Old is the value before a write or delete
+type StorageTrace struct {
+	Contract []byte `json:"contract"`
+	Op       string `json:"op"`
+	Key      []byte `json:"key"`
+	Old      []byte `json:"old,omitempty"`
+	New      []byte `json:"new,omitempty"`
+}
+
+// TrapTrace records where an execution failed. FunctionIndex is the index of the
+// function in the module, imports first, and Offset is the position of the
+// failed instruction in the compiled function code.
+type TrapTrace struct {
+	Contract      []byte `json:"contract"`
+	Depth         int    `json:"depth"`
+	Error         string `json:"error"`
+	FunctionIndex int    `json:"functionIndex"`
+	Offset        int    `json:"offset"`
+}
+
+// Trace is the record of the executions of a Tracer in the order they happened
+type Trace struct {
+	HostCalls []*HostCallTrace `json:"hostCalls"`
+	Storage   []*StorageTrace  `json:"storage"`
+	Traps     []*TrapTrace     `json:"traps,omitempty"`
+}
+
+// Tracer records executions of the contexts it is set to with WithTracer.
+// It is safe for concurrent use.
+type Tracer struct {
+	lock  sync.Mutex
+	trace Trace
+}
+
+func NewTracer() *Tracer {
+	return &Tracer{}
+}
+
+// Trace returns a copy of the records
+func (tracer *Tracer) Trace() *Trace {
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+	return &Trace{
+		HostCalls: append([]*HostCallTrace{}, tracer.trace.HostCalls...),
+		Storage:   append([]*StorageTrace{}, tracer.trace.Storage...),
+		Traps:     append([]*TrapTrace{}, tracer.trace.Traps...),
+	}
+}
+
+// WriteJSON writes the records to w as JSON
+func (tracer *Tracer) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(tracer.Trace())
+}
+
+// Reset discards the records
+func (tracer *Tracer) Reset() {
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+	tracer.trace = Trace{}
+}
+
+func (tracer *Tracer) hostCall(t *HostCallTrace) {
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+	tracer.trace.HostCalls = append(tracer.trace.HostCalls, t)
+}
+
+func (tracer *Tracer) storage(t *StorageTrace) {
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+	tracer.trace.Storage = append(tracer.trace.Storage, t)
+}
+
+func (tracer *Tracer) trap(t *TrapTrace) {
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+	tracer.trace.Traps = append(tracer.trace.Traps, t)
+}
+
+// traceHostCall wraps fn to record its calls
+func (shim *externalResolver) traceHostCall(name string, fn exec.FunctionImport) exec.FunctionImport {
+	tracer := shim.context.tracer
+	return func(vm *exec.VirtualMachine) int64 {
+		args := append([]int64{}, vm.GetCurrentFrame().Locals...)
+		gasBefore := vm.Gas
+		ret := fn(vm)
+		tracer.hostCall(&HostCallTrace{
+			Contract:  shim.context.contractAddress,
+			Depth:     shim.depth,
+			Name:      name,
+			Args:      args,
+			Result:    ret,
+			GasBefore: gasBefore,
+			GasAfter:  vm.Gas,
+		})
+		return ret
+	}
+}
+
+func (shim *externalResolver) tracing() bool {
+	return shim.context.tracer != nil
+}
+
+func (shim *externalResolver) traceStorage(op string, key, old, new []byte) {
+	if !shim.tracing() {
+		return
+	}
+	shim.context.tracer.storage(&StorageTrace{
+		Contract: shim.context.contractAddress,
+		Op:       op,
+		Key:      append([]byte{}, key...),
+		Old:      append([]byte(nil), old...),
+		New:      append([]byte(nil), new...),
+	})
+}
+
+// oldValue returns the value of key before a write when tracing
+func (shim *externalResolver) oldValue(key []byte) []byte {
+	if !shim.tracing() {
+		return nil
+	}
+	value, _ := shim.crtState.GetData(key)
+	return value
+}
+
+// traceTrap records the position of the frame vm failed in
+func (shim *externalResolver) traceTrap(vm *exec.VirtualMachine, err error) {
+	if !shim.tracing() {
+		return
+	}
+	t := &TrapTrace{
+		Contract:      shim.context.contractAddress,
+		Depth:         shim.depth,
+		Error:         err.Error(),
+		FunctionIndex: -1,
+		Offset:        -1,
+	}
+	if vm.CurrentFrame >= 0 {
+		frame := vm.GetCurrentFrame()
+		t.FunctionIndex = frame.FunctionID
+		t.Offset = frame.IP
+	}
+	shim.context.tracer.trap(t)
+}
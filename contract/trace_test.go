@@ -0,0 +1,98 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+func traceModule() ([]byte, int) {
+	m := newTestModule()
+	set := m.importFunc("_set", []byte{i32, i32, i32, i32}, []byte{i32})
+	getLen := m.importFunc("_get_len", []byte{i32, i32}, []byte{i32})
+	del := m.importFunc("_delete", []byte{i32, i32}, []byte{i32})
+	m.putData(0, []byte("keyvalue"))
+	m.entry("run",
+		i32Const(0), i32Const(3), i32Const(3), i32Const(5), callFunc(set), []byte{opDrop},
+		i32Const(0), i32Const(3), callFunc(getLen), []byte{opDrop},
+		i32Const(0), i32Const(3), callFunc(del), []byte{opI64ExtendU})
+	fail := m.entry("fail", i32Const(0), i32Const(3), callFunc(getLen), []byte{opDrop, opUnreachable})
+	return m.bytes(), fail
+}
+
+func TestTrace(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	module, fail := traceModule()
+	deployContract(t, manager, []byte("contract"), module)
+
+	tracer := NewTracer()
+	context := NewContext(WithGasLimit(10000), WithContractAddress([]byte("contract")), WithTracer(tracer))
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "run"})
+	_, err := Call(openContract(t, manager, []byte("contract")), context, ciBuf)
+	assert.NoError(t, err)
+
+	trace := tracer.Trace()
+	assert.Len(t, trace.HostCalls, 3)
+	names := []string{"_set", "_get_len", "_delete"}
+	results := []int64{1, 5, 1}
+	for i, call := range trace.HostCalls {
+		assert.Equal(t, names[i], call.Name)
+		assert.Equal(t, []byte("contract"), call.Contract)
+		assert.Equal(t, results[i], call.Result)
+		assert.True(t, call.GasAfter > call.GasBefore)
+	}
+	assert.Equal(t, []int64{0, 3, 3, 5}, trace.HostCalls[0].Args)
+	assert.Equal(t, []*StorageTrace{
+		{Contract: []byte("contract"), Op: StorageWrite, Key: []byte("key"), New: []byte("value")},
+		{Contract: []byte("contract"), Op: StorageRead, Key: []byte("key"), New: []byte("value")},
+		{Contract: []byte("contract"), Op: StorageDelete, Key: []byte("key"), Old: []byte("value")},
+	}, trace.Storage)
+	assert.Empty(t, trace.Traps)
+
+	tracer.Reset()
+	ciBuf, _ = proto.Marshal(&types.CallInfo{Name: "fail"})
+	_, err = Call(openContract(t, manager, []byte("contract")), context, ciBuf)
+	assert.Error(t, err)
+	trace = tracer.Trace()
+	assert.Len(t, trace.HostCalls, 1)
+	assert.Len(t, trace.Traps, 1)
+	assert.Equal(t, fail, trace.Traps[0].FunctionIndex)
+	assert.True(t, trace.Traps[0].Offset > 0)
+	assert.Equal(t, err.Error(), trace.Traps[0].Error)
+
+	var out bytes.Buffer
+	assert.NoError(t, tracer.WriteJSON(&out))
+	decoded := &Trace{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), decoded))
+	assert.Equal(t, trace, decoded)
+}
+
+func TestTraceNestedCall(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+	deployContract(t, manager, []byte("callee"), calleeModule())
+	deployContract(t, manager, []byte("caller"), callerModule([]byte("callee"), "fail"))
+
+	tracer := NewTracer()
+	context := NewContext(WithGasLimit(10000), WithContractAddress([]byte("caller")), WithStateManager(manager), WithTracer(tracer))
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "invoke"})
+	_, err := Call(openContract(t, manager, []byte("caller")), context, ciBuf)
+	assert.NoError(t, err)
+
+	trace := tracer.Trace()
+	assert.Len(t, trace.HostCalls, 2)
+	assert.Equal(t, "_set", trace.HostCalls[0].Name)
+	assert.Equal(t, 1, trace.HostCalls[0].Depth)
+	assert.Equal(t, "_call_contract", trace.HostCalls[1].Name)
+	assert.Equal(t, int64(-1), trace.HostCalls[1].Result)
+	assert.Len(t, trace.Traps, 1)
+	assert.Equal(t, []byte("callee"), trace.Traps[0].Contract)
+	assert.Equal(t, 1, trace.Traps[0].Depth)
+}
@@ -0,0 +1,115 @@
+package contract
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/go-interpreter/wagon/wasm"
+	"github.com/zhigui-projects/zwasm/state"
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+// migrateEntry is the export Upgrade calls with the old code hash as argument
+const migrateEntry = "migrate"
+
+var (
+	errNotAuthorized      = errors.New("sender is not allowed to upgrade the contract")
+	errUpgradeInitCall    = errors.New("upgrade code must not carry an init call")
+	errUpgradeSameCode    = errors.New("upgrade code is the deployed code")
+	errUpgradeInvalidCode = errors.New("invalid upgrade code")
+)
+
+// Upgrade replaces the code of the contract in crtState and keeps its storage.
+// Only the "Creator" of the contract or the "Admin" stored by the contract can upgrade it.
+// If the new code exports migrate, it is called with the old code hash as its only
+// argument; when it fails the upgrade is discarded and the receipt is returned with the error.
+func Upgrade(crtState *state.ContractState, context *Context, code []byte) (receipt *Receipt, err error) {
+	oldHash := crtState.CodeHash
+	if oldHash == nil {
+		return nil, errNoContract
+	}
+	if !canUpgrade(crtState, context.senderAddress) {
+		return nil, errNotAuthorized
+	}
+	if ci, err := initCallInfo(code); err != nil || ci != nil {
+		return nil, errUpgradeInitCall
+	}
+
+	snapshot := crtState.Snapshot()
+	defer func() {
+		if err != nil {
+			crtState.Rollback(snapshot)
+			crtState.CodeHash = oldHash
+		}
+	}()
+
+	module := deployedModule(code)
+	if module == nil {
+		return nil, errUpgradeInvalidCode
+	}
+	m, err := decodeModule(module)
+	if err != nil {
+		return nil, err
+	}
+	if err = validateModule(m, newExternalResolver(context, crtState), ""); err != nil {
+		return nil, err
+	}
+	if hasStartFunction(m) {
+		return nil, errStartFunc
+	}
+	contractABI, abiData, err := deployedABI(m, context.abi)
+	if err != nil {
+		return nil, err
+	}
+
+	contract, _, deployGas, err := setCode(crtState, code, context)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(crtState.CodeHash, oldHash) {
+		return nil, errUpgradeSameCode
+	}
+	if contractABI != nil {
+		err = crtState.SetABI(abiData)
+	} else {
+		err = crtState.DeleteABI()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !exportsFunction(m, migrateEntry) {
+		return newReceipt(0, deployGas, nil, nil), nil
+	}
+	// migrate runs on the gas left after the deployment
+	migrateContext := *context
+	migrateContext.gasLimit -= deployGas
+	ci := &types.CallInfo{Name: migrateEntry, Args: [][]byte{oldHash}}
+	receipt, err = execute(newExternalResolver(&migrateContext, crtState), contract, ci)
+	if receipt != nil {
+		receipt.GasUsed += deployGas
+	}
+	return receipt, err
+}
+
+// canUpgrade reports whether sender is the creator or the admin of the contract
+func canUpgrade(crtState *state.ContractState, sender []byte) bool {
+	if len(sender) == 0 {
+		return false
+	}
+	for _, key := range []string{"Creator", "Admin"} {
+		value, err := crtState.GetData([]byte(key))
+		if err == nil && bytes.Equal(value, sender) {
+			return true
+		}
+	}
+	return false
+}
+
+func exportsFunction(m *wasm.Module, name string) bool {
+	if m.Export == nil {
+		return false
+	}
+	export, ok := m.Export.Entries[name]
+	return ok && export.Kind == wasm.ExternalFunction
+}
@@ -0,0 +1,126 @@
+package contract
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhigui-projects/zwasm/state"
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+// versionModule returns version from get, it stores its migrate arguments under "migrated"
+func versionModule(version int64, migrate bool, failMigrate bool) []byte {
+	m := newTestModule()
+	set := m.importFunc("_set", []byte{i32, i32, i32, i32}, []byte{i32})
+	m.putData(0, []byte("migrated"))
+	m.entry("get", i64Const(version))
+	if migrate {
+		body := concat(i32Const(0), i32Const(8), getLocal(0), getLocal(1), callFunc(set), []byte{opI64ExtendU})
+		if failMigrate {
+			body = concat(body, []byte{opUnreachable})
+		}
+		m.entry(migrateEntry, body)
+	}
+	return m.bytes()
+}
+
+func callVersion(t *testing.T, crtState *state.ContractState) int64 {
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "get"})
+	receipt, err := Call(crtState, NewContext(WithGasLimit(10000)), ciBuf)
+	assert.NoError(t, err)
+	return receipt.Ret
+}
+
+func TestUpgrade(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+
+	crtState := openContract(t, manager, []byte("contract"))
+	_, err := Create(crtState, NewContext(WithGasLimit(10000), WithSender([]byte("creator"))), deployCode(versionModule(1, false, false), nil))
+	assert.NoError(t, err)
+	crtState.SetData([]byte("key"), []byte("value"))
+	oldHash := crtState.CodeHash
+	assert.Equal(t, int64(1), callVersion(t, crtState))
+
+	_, err = Upgrade(crtState, NewContext(WithGasLimit(10000), WithSender([]byte("other"))), deployCode(versionModule(2, true, false), nil))
+	assert.Equal(t, errNotAuthorized, err)
+
+	receipt, err := Upgrade(crtState, NewContext(WithGasLimit(10000), WithSender([]byte("creator"))), deployCode(versionModule(2, true, false), nil))
+	assert.NoError(t, err)
+	assert.Equal(t, ReceiptSuccess, receipt.Status)
+	assert.NotEqual(t, oldHash, crtState.CodeHash)
+	assert.Equal(t, int64(2), callVersion(t, crtState))
+
+	migrated, err := crtState.GetData([]byte("migrated"))
+	assert.NoError(t, err)
+	assert.True(t, bytes.Contains(migrated, oldHash))
+	value, err := crtState.GetData([]byte("key"))
+	assert.NoError(t, err)
+	assert.Equal(t, "value", string(value))
+}
+
+func TestUpgradeMigrateFail(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+
+	crtState := openContract(t, manager, []byte("contract"))
+	_, err := Create(crtState, NewContext(WithGasLimit(10000), WithSender([]byte("creator"))), deployCode(versionModule(1, false, false), nil))
+	assert.NoError(t, err)
+	crtState.SetData([]byte("Admin"), []byte("admin"))
+	oldHash := crtState.CodeHash
+	assert.Equal(t, int64(1), callVersion(t, crtState))
+
+	receipt, err := Upgrade(crtState, NewContext(WithGasLimit(10000), WithSender([]byte("admin"))), deployCode(versionModule(3, true, true), nil))
+	assert.Error(t, err)
+	assert.Equal(t, ReceiptFailed, receipt.Status)
+	assert.Equal(t, oldHash, crtState.CodeHash)
+	assert.Equal(t, int64(1), callVersion(t, crtState))
+	migrated, err := crtState.GetData([]byte("migrated"))
+	assert.NoError(t, err)
+	assert.Nil(t, migrated)
+
+	_, err = Upgrade(crtState, NewContext(WithGasLimit(10000), WithSender([]byte("admin"))), deployCode(versionModule(1, false, false), nil))
+	assert.Equal(t, errUpgradeSameCode, err)
+
+	initCall, _ := proto.Marshal(&types.CallInfo{Name: "get"})
+	_, err = Upgrade(crtState, NewContext(WithGasLimit(10000), WithSender([]byte("admin"))), deployCode(versionModule(2, false, false), initCall))
+	assert.Equal(t, errUpgradeInitCall, err)
+	assert.Equal(t, oldHash, crtState.CodeHash)
+}
+
+func TestUpgradeGasLimit(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	manager := newTestManager(store)
+
+	crtState := openContract(t, manager, []byte("contract"))
+	_, err := Create(crtState, NewContext(WithGasLimit(10000), WithSender([]byte("creator"))), deployCode(versionModule(1, false, false), nil))
+	assert.NoError(t, err)
+
+	schedule := DefaultGasSchedule()
+	schedule.DeployPerByte = 1
+	module := versionModule(2, true, false)
+	deployGas := uint64(4 + len(module))
+	upgrade := func(gasLimit uint64) (*Receipt, error) {
+		context := NewContext(WithGasLimit(gasLimit), WithSender([]byte("creator")), WithGasSchedule(schedule))
+		return Upgrade(crtState, context, deployCode(module, nil))
+	}
+
+	// migrate only gets the gas left after the deployment
+	for _, gasLimit := range []uint64{deployGas, deployGas + 5} {
+		receipt, err := upgrade(gasLimit)
+		assert.Equal(t, errGasExceed, err)
+		assert.True(t, receipt.GasUsed <= gasLimit)
+		assert.Equal(t, int64(1), callVersion(t, crtState))
+	}
+
+	receipt, err := upgrade(10000)
+	assert.NoError(t, err)
+	assert.True(t, receipt.GasUsed > deployGas)
+	assert.True(t, receipt.GasUsed <= 10000)
+	assert.Equal(t, int64(2), callVersion(t, crtState))
+}
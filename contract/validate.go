@@ -0,0 +1,238 @@
+package contract
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/go-interpreter/wagon/disasm"
+	"github.com/go-interpreter/wagon/wasm"
+	"github.com/pkg/errors"
+	"github.com/zhigui-projects/zwasm/abi"
+)
+
+const maxMemoryPages = 256
+const maxTableSize = defaultTableSize
+
+var (
+	errStartFunc  = errors.New("invalid module: start function is only allowed on deploy")
+	errStartSig   = errors.New("invalid module: start function must be a function of the module of type () -> ()")
+	errNoExport   = errors.New("invalid module: no exported function")
+	errNoCodeBody = errors.New("invalid module: function has no body")
+)
+
+// decodeModule decodes the wasm module code, a deployment decodes it once for all checks
+func decodeModule(code []byte) (*wasm.Module, error) {
+	m, err := wasm.ReadModule(bytes.NewReader(code), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid module")
+	}
+	return m, nil
+}
+
+// validateModule checks that m executes deterministically with the host
+// functions of resolver. entry is the name of the init function, if any.
+func validateModule(m *wasm.Module, resolver *externalResolver, entry string) error {
+	if err := validateStart(m); err != nil {
+		return err
+	}
+	if err := validateImports(m, resolver); err != nil {
+		return err
+	}
+	if err := validateLimits(m); err != nil {
+		return err
+	}
+	if err := validateExports(m, entry); err != nil {
+		return err
+	}
+	return validateFloat(m)
+}
+
+func validateImports(m *wasm.Module, resolver *externalResolver) error {
+	if m.Import == nil {
+		return nil
+	}
+	for _, imp := range m.Import.Entries {
+		switch imp.Type.Kind() {
+		case wasm.ExternalFunction:
+			fn, _ := resolver.context.getHostRegistry().lookup(imp.ModuleName, imp.FieldName)
+			if fn == nil {
+				return &ImportError{Module: imp.ModuleName, Field: imp.FieldName, Kind: "function"}
+			}
+			idx := int(imp.Type.(wasm.FuncImport).Type)
+			if m.Types == nil || idx >= len(m.Types.Entries) {
+				return fmt.Errorf("invalid module: import %s.%s has no type", imp.ModuleName, imp.FieldName)
+			}
+			if !fn.matchSignature(m.Types.Entries[idx]) {
+				return fmt.Errorf("invalid module: import %s.%s does not match the host function signature", imp.ModuleName, imp.FieldName)
+			}
+		case wasm.ExternalGlobal:
+			if _, ok := resolver.resolveGlobal(imp.ModuleName, imp.FieldName); !ok {
+				return &ImportError{Module: imp.ModuleName, Field: imp.FieldName, Kind: "global"}
+			}
+		case wasm.ExternalMemory:
+			// life does not resolve memory imports, the module defines its memory
+			return &ImportError{Module: imp.ModuleName, Field: imp.FieldName, Kind: "memory"}
+		case wasm.ExternalTable:
+			return &ImportError{Module: imp.ModuleName, Field: imp.FieldName, Kind: "table"}
+		default:
+			return fmt.Errorf("invalid module: unsupported import %s.%s", imp.ModuleName, imp.FieldName)
+		}
+	}
+	return nil
+}
+
+// validateStart checks the start function, which runs when the module is deployed
+func validateStart(m *wasm.Module) error {
+	if m.Start == nil {
+		return nil
+	}
+	idx := int(m.Start.Index) - importedFunctions(m)
+	if idx < 0 || idx >= len(m.FunctionIndexSpace) {
+		return errStartSig
+	}
+	sig := m.FunctionIndexSpace[idx].Sig
+	if len(sig.ParamTypes) != 0 || len(sig.ReturnTypes) != 0 {
+		return errStartSig
+	}
+	return nil
+}
+
+func validateLimits(m *wasm.Module) error {
+	if m.Memory != nil {
+		for _, mem := range m.Memory.Entries {
+			if exceedLimits(mem.Limits, maxMemoryPages) {
+				return fmt.Errorf("invalid module: memory exceeds %d pages", maxMemoryPages)
+			}
+		}
+	}
+	if m.Table != nil {
+		for _, table := range m.Table.Entries {
+			if exceedLimits(table.Limits, maxTableSize) {
+				return fmt.Errorf("invalid module: table exceeds %d elements", maxTableSize)
+			}
+		}
+	}
+	return nil
+}
+
+func exceedLimits(limits wasm.ResizableLimits, max uint32) bool {
+	if limits.Initial > max {
+		return true
+	}
+	return limits.Flags&1 == 1 && limits.Maximum > max
+}
+
+func validateExports(m *wasm.Module, entry string) error {
+	if m.Export == nil {
+		return errNoExport
+	}
+	funcs := 0
+	for _, export := range m.Export.Entries {
+		if export.Kind == wasm.ExternalFunction {
+			funcs++
+		}
+	}
+	if funcs == 0 {
+		return errNoExport
+	}
+	if entry == "" {
+		return nil
+	}
+	export, ok := m.Export.Entries[entry]
+	if !ok || export.Kind != wasm.ExternalFunction {
+		return fmt.Errorf("invalid module: init function %s is not exported", entry)
+	}
+	return nil
+}
+
+// validateABI checks that every function of contractABI is exported
+// with the (args ptr, args len) -> i64 signature call expects.
+func validateABI(m *wasm.Module, contractABI *abi.ABI) error {
+	imports := importedFunctions(m)
+	for _, fn := range contractABI.Functions {
+		var export wasm.ExportEntry
+		ok := false
+		if m.Export != nil {
+			export, ok = m.Export.Entries[fn.Name]
+		}
+		if !ok || export.Kind != wasm.ExternalFunction {
+			return fmt.Errorf("invalid abi: function %s is not exported", fn.Name)
+		}
+		idx := int(export.Index) - imports
+		if idx < 0 || idx >= len(m.FunctionIndexSpace) {
+			return fmt.Errorf("invalid abi: function %s is imported", fn.Name)
+		}
+		sig := m.FunctionIndexSpace[idx].Sig
+		if !isEntrySig(sig) {
+			return fmt.Errorf("invalid abi: function %s has signature %s", fn.Name, sig)
+		}
+	}
+	return nil
+}
+
+// importedFunctions returns the number of functions m imports, they come first in the function index space
+func importedFunctions(m *wasm.Module) int {
+	imports := 0
+	if m.Import != nil {
+		for _, imp := range m.Import.Entries {
+			if imp.Type.Kind() == wasm.ExternalFunction {
+				imports++
+			}
+		}
+	}
+	return imports
+}
+
+func isEntrySig(sig *wasm.FunctionSig) bool {
+	return len(sig.ParamTypes) == 2 && sig.ParamTypes[0] == wasm.ValueTypeI32 && sig.ParamTypes[1] == wasm.ValueTypeI32 &&
+		len(sig.ReturnTypes) == 1 && sig.ReturnTypes[0] == wasm.ValueTypeI64
+}
+
+func validateFloat(m *wasm.Module) error {
+	if m.Types != nil {
+		for i, sig := range m.Types.Entries {
+			for _, t := range append(append([]wasm.ValueType{}, sig.ParamTypes...), sig.ReturnTypes...) {
+				if isFloatType(t) {
+					return fmt.Errorf("invalid module: type %d uses floating point %s", i, t)
+				}
+			}
+		}
+	}
+	if m.Global != nil {
+		for i, global := range m.Global.Globals {
+			if isFloatType(global.Type.Type) {
+				return fmt.Errorf("invalid module: global %d uses floating point %s", i, global.Type.Type)
+			}
+		}
+	}
+	for i, fn := range m.FunctionIndexSpace {
+		if fn.Body == nil {
+			return errNoCodeBody
+		}
+		for _, local := range fn.Body.Locals {
+			if isFloatType(local.Type) {
+				return fmt.Errorf("invalid module: function %d uses floating point %s", i, local.Type)
+			}
+		}
+		d, err := disasm.Disassemble(fn, m)
+		if err != nil {
+			return errors.Wrapf(err, "invalid module: function %d", i)
+		}
+		for _, ins := range d.Code {
+			if isFloatOp(ins.Op.Name) {
+				return fmt.Errorf("invalid module: function %d uses floating point instruction %s", i, ins.Op.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func isFloatType(t wasm.ValueType) bool {
+	return t == wasm.ValueTypeF32 || t == wasm.ValueTypeF64
+}
+
+func isFloatOp(name string) bool {
+	return strings.HasPrefix(name, "f32.") || strings.HasPrefix(name, "f64.") ||
+		strings.HasSuffix(name, "/f32") || strings.HasSuffix(name, "/f64")
+}
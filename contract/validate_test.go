@@ -0,0 +1,105 @@
+package contract
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhigui-projects/zwasm/types"
+)
+
+func validModule() *testModule {
+	m := newTestModule()
+	m.importFunc("_set", []byte{i32, i32, i32, i32}, []byte{i32})
+	m.entry("invoke", i64Const(1))
+	return m
+}
+
+// validateCode decodes code and validates it like a deployment
+func validateCode(code []byte, resolver *externalResolver, entry string) error {
+	m, err := decodeModule(code)
+	if err != nil {
+		return err
+	}
+	return validateModule(m, resolver, entry)
+}
+
+// importModule returns a module which only imports entry
+func importModule(entry []byte) []byte {
+	return concat([]byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}, section(2, vector(1, entry)))
+}
+
+func TestValidateModule(t *testing.T) {
+	resolver := newExternalResolver(&Context{}, nil)
+
+	assert.NoError(t, validateCode(validModule().bytes(), resolver, ""))
+	assert.NoError(t, validateCode(validModule().bytes(), resolver, "invoke"))
+
+	code, _ := loadCode()
+	assert.NoError(t, validateCode(code, resolver, "invoke"))
+
+	assert.Error(t, validateCode([]byte("abc"), resolver, ""))
+
+	m := validModule()
+	m.start = m.function("", nil, nil)
+	assert.NoError(t, validateCode(m.bytes(), resolver, ""))
+
+	m = validModule()
+	m.start = m.function("", []byte{i32}, nil)
+	assert.Equal(t, errStartSig, validateCode(m.bytes(), resolver, ""))
+
+	m = validModule()
+	m.start = m.importFunc("_get_len", []byte{i32, i32}, []byte{i32})
+	assert.Equal(t, errStartSig, validateCode(m.bytes(), resolver, ""))
+
+	m = validModule()
+	m.importFunc("_unknown", nil, nil)
+	assert.EqualError(t, validateCode(m.bytes(), resolver, ""), "invalid module: unknown function import env._unknown")
+
+	m = validModule()
+	m.imports = append(m.imports, testImport{module: "wasi", field: "_set", params: []byte{i32, i32, i32, i32}, results: []byte{i32}})
+	assert.EqualError(t, validateCode(m.bytes(), resolver, ""), "invalid module: unknown function import wasi._set")
+
+	memory := concat(name("env"), name("memory"), []byte{0x02, 0x00, 0x01})
+	assert.EqualError(t, validateCode(importModule(memory), resolver, ""), "invalid module: unknown memory import env.memory")
+	table := concat(name("env"), name("table"), []byte{0x01, 0x70, 0x00, 0x01})
+	assert.EqualError(t, validateCode(importModule(table), resolver, ""), "invalid module: unknown table import env.table")
+
+	m = validModule()
+	m.entry("float", []byte{opF32Const, 0, 0, 0, 0, opDrop}, i64Const(1))
+	assert.EqualError(t, validateCode(m.bytes(), resolver, ""), "invalid module: function 1 uses floating point instruction f32.const")
+
+	m = validModule()
+	m.function("sum", []byte{f32}, nil)
+	assert.EqualError(t, validateCode(m.bytes(), resolver, ""), "invalid module: type 2 uses floating point f32")
+
+	m = validModule()
+	m.pages = maxMemoryPages + 1
+	assert.EqualError(t, validateCode(m.bytes(), resolver, ""), "invalid module: memory exceeds 256 pages")
+
+	assert.EqualError(t, validateCode(validModule().bytes(), resolver, "init"), "invalid module: init function init is not exported")
+
+	m = newTestModule()
+	m.function("", nil, nil)
+	assert.Equal(t, errNoExport, validateCode(m.bytes(), resolver, ""))
+}
+
+func TestCreateInvalidModule(t *testing.T) {
+	store := createDB(t)
+	defer closeDB(t, store)
+	crtState, _ := createContractState(t, store)
+
+	m := validModule()
+	m.importFunc("_unknown", nil, nil)
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender")}
+	_, err := Create(crtState, context, deployCode(m.bytes(), nil))
+	assert.Error(t, err)
+
+	ciBuf, _ := proto.Marshal(&types.CallInfo{Name: "init"})
+	_, err = Create(crtState, context, deployCode(validModule().bytes(), ciBuf))
+	assert.Error(t, err)
+
+	assert.Nil(t, crtState.CodeHash)
+	creator, _ := crtState.GetData([]byte("Creator"))
+	assert.Nil(t, creator)
+}
@@ -4,10 +4,9 @@ import (
 	"encoding/binary"
 	"fmt"
 
-	"github.com/perlin-network/life/compiler"
 	"github.com/perlin-network/life/exec"
+	"github.com/perlin-network/life/utils"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
 	"github.com/zhigui-projects/zwasm/state"
 	"github.com/zhigui-projects/zwasm/types"
 )
@@ -16,16 +15,26 @@ const defaultMemoryPages = 128
 const defaultTableSize = 65536
 const gasByKBSize = 1
 
+// errLifeGasExceed is the message of the panic raised by life on gas exhaustion
+const errLifeGasExceed = "gas limit exceeded"
+
 var (
-	errCreateVM            = errors.New("failed to create virtual machine")
-	errNotSupportStartFunc = errors.New("not support start function")
-	errDeployContract      = errors.New("cannot deploy contract")
-	gasPolicy              = &compiler.SimpleGasPolicy{GasPerInstruction: 1}
+	errCreateVM       = errors.New("failed to create virtual machine")
+	errDeployContract = errors.New("cannot deploy contract")
+	errReadOnly       = errors.New("state modification is not allowed in read-only call")
 )
 
 type externalResolver struct {
-	context  *Context
-	crtState *state.ContractState
+	context    *Context
+	crtState   *state.ContractState
+	events     []*Event
+	returnData []byte
+	journal    []balanceChange
+	destructs  []*externalResolver
+	parent     *externalResolver
+	depth      int
+	start      bool // run the start function before the call
+	starting   bool // the start function is running
 }
 
 func newExternalResolver(context *Context, crtState *state.ContractState) *externalResolver {
@@ -33,88 +42,88 @@ func newExternalResolver(context *Context, crtState *state.ContractState) *exter
 }
 
 func (shim *externalResolver) ResolveGlobal(module, field string) int64 {
-	log.Debug().Msgf("Resolve global: %s %s\n", module, field)
+	shim.context.getLogger().Debug().Msgf("Resolve global: %s %s\n", module, field)
+	global, ok := shim.resolveGlobal(module, field)
+	if !ok {
+		panic(&ImportError{Module: module, Field: field, Kind: "global"})
+	}
+	return global
+}
+
+func (shim *externalResolver) resolveGlobal(module, field string) (int64, bool) {
 	switch module {
 	case "env":
 		switch field {
 		case "zwasm_magic":
-			return 76
+			return 76, true
 		default:
-			panic(fmt.Errorf("unknown field: %s", field))
+			return 0, false
 		}
 	default:
-		panic(fmt.Errorf("unknown module: %s", module))
+		return 0, false
 	}
 }
 
 func (shim *externalResolver) ResolveFunc(module, field string) exec.FunctionImport {
-	log.Debug().Msgf("Resolve func: %s %s\n", module, field)
-	switch module {
-	case "env":
-		switch field {
-		case "_get_len":
-			return func(vm *exec.VirtualMachine) int64 {
-				ptr := int(uint32(vm.GetCurrentFrame().Locals[0]))
-				keyLen := int(uint32(vm.GetCurrentFrame().Locals[1]))
-				key := vm.Memory[ptr: ptr+keyLen]
-
-				value, err := shim.crtState.GetData(key)
-				if err != nil {
-					log.Error().Err(err)
-					return -1
-				}
-
-				valueLen := len(value)
-				return int64(valueLen)
-			}
-		case "_set":
-			return func(vm *exec.VirtualMachine) int64 {
-				keyPtr := int(uint32(vm.GetCurrentFrame().Locals[0]))
-				keyLen := int(uint32(vm.GetCurrentFrame().Locals[1]))
-				key := vm.Memory[keyPtr: keyPtr+keyLen]
-
-				valuePtr := int(uint32(vm.GetCurrentFrame().Locals[2]))
-				valueLen := int(uint32(vm.GetCurrentFrame().Locals[3]))
-				value := vm.Memory[valuePtr: valuePtr+valueLen]
-
-				err := shim.crtState.SetData(key, value)
-				if err != nil {
-					log.Error().Err(err)
-					return -1
-				} else {
-					return 1
-				}
-			}
-		case "_get":
-			return func(vm *exec.VirtualMachine) int64 {
-				keyPtr := int(uint32(vm.GetCurrentFrame().Locals[0]))
-				keyLen := int(uint32(vm.GetCurrentFrame().Locals[1]))
-				key := vm.Memory[keyPtr: keyPtr+keyLen]
-
-				outValuePtr := int(uint32(vm.GetCurrentFrame().Locals[2]))
-				value, err := shim.crtState.GetData(key)
-				if err != nil {
-					log.Error().Err(err)
-					return -1
-				} else {
-					outValueMem := vm.Memory[outValuePtr: outValuePtr+len(value)]
-					copy(outValueMem, value)
-					return 1
-				}
+	shim.context.getLogger().Debug().Msgf("Resolve func: %s %s\n", module, field)
+	fn := shim.resolveFunc(module, field)
+	if fn == nil {
+		panic(&ImportError{Module: module, Field: field, Kind: "function"})
+	}
+	fn = recoverHostCall(module, field, fn)
+	if shim.tracing() {
+		return shim.traceHostCall(field, fn)
+	}
+	return fn
+}
+
+// resolveFunc returns nil when module does not provide field
+func (shim *externalResolver) resolveFunc(module, field string) exec.FunctionImport {
+	fn, name := shim.context.getHostRegistry().lookup(module, field)
+	if fn == nil {
+		return nil
+	}
+	return shim.hostFunc(name, fn)
+}
+
+// useGas charges gas to vm, it returns false when the gas limit of vm is exceeded.
+func (shim *externalResolver) useGas(vm *exec.VirtualMachine, gas uint64) bool {
+	newGas := vm.Gas + gas
+	if newGas < vm.Gas {
+		return false
+	}
+	if newGas > vm.Config.GasLimit {
+		return false
+	}
+	vm.Gas = newGas
+	return true
+}
+
+// recoverHostCall returns fn trapping with a *HostError instead of panicking.
+// Host functions run outside of the recovery of the vm, a panic would escape vm.Run.
+func recoverHostCall(module, field string, fn exec.FunctionImport) exec.FunctionImport {
+	return func(vm *exec.VirtualMachine) (ret int64) {
+		defer func() {
+			if r := recover(); r != nil {
+				ret = trap(vm, &HostError{Module: module, Field: field, Reason: fmt.Sprint(r)})
 			}
-		default:
-			panic(fmt.Errorf("unknown field: %s", field))
-		}
-	default:
-		panic(fmt.Errorf("unknown module: %s", module))
+		}()
+		return fn(vm)
 	}
 }
 
+// trap stops the execution of vm, vm.Run returns err.
+func trap(vm *exec.VirtualMachine, err error) int64 {
+	vm.Exited = true
+	vm.ExitError = err
+	return -1
+}
+
 func codeLength(val []byte) uint32 {
 	return binary.LittleEndian.Uint32(val[0:])
 }
 
-func setCode(contractState *state.ContractState, code []byte, gasLimit uint64) ([]byte, uint32, uint64, error) {
+func setCode(contractState *state.ContractState, code []byte, context *Context) ([]byte, uint32, uint64, error) {
 	if len(code) <= 4 {
 		err := fmt.Errorf("invalid code (%d bytes is too short)", len(code))
 		return nil, 0, 0, err
@@ -125,9 +134,13 @@ func setCode(contractState *state.ContractState, code []byte, gasLimit uint64) (
 		return nil, 0, 0, err
 	}
 	sCode := code[4:codeLen]
+	if len(sCode) <= 4 {
+		err := fmt.Errorf("invalid code (%d bytes is too short)", len(sCode))
+		return nil, 0, 0, err
+	}
 
-	gas := uint64(codeLength(sCode[0:]) / 1024 * gasByKBSize)
-	if gas > gasLimit {
+	gas := context.getGasSchedule().deployGas(len(sCode))
+	if gas > context.gasLimit {
 		return nil, 0, 0, errGasExceed
 	}
 
@@ -166,25 +179,51 @@ func getCode(contractState *state.ContractState, code []byte) []byte {
 	return val[4: 4+l]
 }
 
-func call(code []byte, callInfo *types.CallInfo, resolver *externalResolver) (int64, uint64, error) {
-	vm, err := exec.NewVirtualMachine(code, exec.VMConfig{
+// deployedModule returns the wasm module carried by a deployment, nil when code is malformed
+func deployedModule(code []byte) []byte {
+	if len(code) <= 4 {
+		return nil
+	}
+	codeLen := codeLength(code)
+	if codeLen <= 4 || uint32(len(code)) < codeLen {
+		return nil
+	}
+	return getCode(nil, code[4:codeLen])
+}
+
+func call(code []byte, callInfo *types.CallInfo, resolver *externalResolver) (*Receipt, error) {
+	// life treats a gas limit of 0 as unlimited, a context without gas runs nothing
+	if resolver.context.gasLimit == 0 {
+		return newReceipt(0, 0, resolver, errGasExceed), errGasExceed
+	}
+	vm, err := newVirtualMachine(code, exec.VMConfig{
 		DefaultMemoryPages: defaultMemoryPages,
 		DefaultTableSize:   defaultTableSize,
+		MaxMemoryPages:     maxMemoryPages,
+		MaxTableSize:       maxTableSize,
 		GasLimit:           resolver.context.gasLimit,
-	}, resolver, gasPolicy)
+	}, resolver)
 
 	if err != nil {
-		return -1, 0, errCreateVM
+		if _, ok := err.(*ImportError); ok {
+			return nil, err
+		}
+		return nil, errCreateVM
 	}
 
-	if vm.Module.Base.Start != nil {
-		return -1, 0, errNotSupportStartFunc
+	if resolver.start && vm.Module.Base.Start != nil {
+		if err = resolver.runStart(vm); err != nil {
+			return runResult(vm, resolver, -1, err)
+		}
+	}
+	if callInfo == nil {
+		return newReceipt(0, vm.Gas, resolver, nil), nil
 	}
 
 	entryId, ok := vm.GetFunctionExport(callInfo.Name)
 	if !ok {
 		err = errors.Errorf("function %s not found", callInfo.Name)
-		return -1, 0, err
+		return nil, err
 	}
 
 	argsLen := len(callInfo.Args)
@@ -195,11 +234,43 @@ func call(code []byte, callInfo *types.CallInfo, resolver *externalResolver) (in
 	}
 
 	ret, err := vm.Run(entryId, int64(outArgsPtr), int64(outArgsLen))
+	return runResult(vm, resolver, ret, err)
+}
+
+// runResult returns the receipt of a run of vm which returned ret or failed with err
+func runResult(vm *exec.VirtualMachine, resolver *externalResolver, ret int64, err error) (*Receipt, error) {
 	if err != nil {
-		return ret, vm.Gas, err
+		resolver.traceTrap(vm, err)
+	}
+	if err != nil && (err == errGasExceed || err.Error() == errLifeGasExceed) {
+		return newReceipt(ret, vm.Config.GasLimit, resolver, errGasExceed), errGasExceed
+	}
+	return newReceipt(ret, vm.Gas, resolver, err), err
+}
+
+// newVirtualMachine instantiates code from the module cache of the context,
+// the code is compiled with the gas schedule of the context and cached by its code hash on a miss.
+// Import resolution failures are returned as *ImportError.
+func newVirtualMachine(code []byte, config exec.VMConfig, resolver *externalResolver) (_ *exec.VirtualMachine, retErr error) {
+	defer utils.CatchPanic(&retErr)
+
+	schedule := resolver.context.getGasSchedule()
+	codeHash := resolver.crtState.GetCodeHash()
+	if codeHash == nil {
+		return exec.NewVirtualMachine(code, config, resolver, schedule)
 	}
+	key := string(codeHash) + schedule.policyKey()
 
-	return ret, vm.Gas, nil
+	cache := resolver.context.getModuleCache()
+	if m := cache.get(key); m != nil {
+		return m.instantiate(config, resolver)
+	}
+	vm, err := exec.NewVirtualMachine(code, config, resolver, schedule)
+	if err != nil {
+		return nil, err
+	}
+	cache.put(newCompiledModule(key, vm, len(code)))
+	return vm, nil
 }
 
 func injectArgs(argsLen int, outArgsPtr int, vm *exec.VirtualMachine, outArgsLen int, callInfo *types.CallInfo) (int, int) {
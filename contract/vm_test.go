@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/aergoio/aergo-lib/db"
+	"github.com/perlin-network/life/exec"
 	"github.com/stretchr/testify/assert"
 	"github.com/zhigui-projects/zwasm/common"
 	"github.com/zhigui-projects/zwasm/state"
@@ -22,9 +23,9 @@ func TestCall(t *testing.T) {
 	defer closeDB(t, store)
 	crtState, err := createContractState(t, store)
 	context := &Context{gasLimit: 10000, senderAddress: []byte("sender")}
-	_, usedGas, err := call(code, ci, newExternalResolver(context, crtState))
-	assert.True(t, usedGas > 0)
+	receipt, err := call(code, ci, newExternalResolver(context, crtState))
 	assert.NoError(t, err)
+	assert.True(t, receipt.GasUsed > 0)
 
 	val, err := crtState.GetData([]byte("abc"))
 	val1, err := crtState.GetData([]byte("abc1"))
@@ -45,7 +46,7 @@ func TestGetSetCode(t *testing.T) {
 	codeLenBytes := make([]byte, 4)
 	binary.LittleEndian.PutUint32(codeLenBytes, uint32(codeLen+4))
 	codeLenBytes = append(codeLenBytes, code...)
-	setCode(crtState, codeLenBytes, 10000)
+	setCode(crtState, codeLenBytes, &Context{gasLimit: 10000})
 	ret1 := getCode(crtState, nil)
 	assert.Nil(t, ret1)
 
@@ -56,7 +57,7 @@ func TestGetSetCode(t *testing.T) {
 	totalCodeLenBytes := make([]byte, 4)
 	binary.LittleEndian.PutUint32(totalCodeLenBytes, uint32(4+4+codeLen))
 	totalCodeLenBytes = append(totalCodeLenBytes, correctCodeLenBytes...)
-	setCode(crtState1, totalCodeLenBytes, 10000)
+	setCode(crtState1, totalCodeLenBytes, &Context{gasLimit: 10000})
 	ret2 := getCode(crtState1, nil)
 	assert.NotNil(t, ret2)
 	assert.Equal(t, code, ret2)
@@ -93,3 +94,120 @@ func closeDB(t *testing.T, store db.DB) {
 	store.Close()
 	os.RemoveAll(t.Name())
 }
+
+func TestDelete(t *testing.T) {
+	m := newTestModule()
+	del := m.importFunc("_delete", []byte{i32, i32}, []byte{i32})
+	m.putData(0, []byte("abc"))
+	m.entry("remove", i32Const(0), i32Const(3), callFunc(del), []byte{opI64ExtendU})
+
+	store := createDB(t)
+	defer closeDB(t, store)
+	crtState, err := createContractState(t, store)
+	assert.NoError(t, err)
+	assert.NoError(t, crtState.SetData([]byte("abc"), []byte("xyz")))
+
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender")}
+	receipt, err := call(m.bytes(), &types.CallInfo{Name: "remove"}, newExternalResolver(context, crtState))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), receipt.Ret)
+
+	val, err := crtState.GetData([]byte("abc"))
+	assert.NoError(t, err)
+	assert.Nil(t, val)
+}
+
+func TestEmitEvent(t *testing.T) {
+	m := newTestModule()
+	emit := m.importFunc("_emit_event", []byte{i32, i32, i32, i32}, []byte{i32})
+	m.putData(0, []byte("transfer"))
+	m.putData(8, []byte("payload"))
+	m.entry("emit",
+		i32Const(0), i32Const(8), i32Const(8), i32Const(7), callFunc(emit), []byte{opDrop},
+		i32Const(0), i32Const(8), i32Const(8), i32Const(3), callFunc(emit), []byte{opI64ExtendU})
+	m.entry("fail",
+		i32Const(0), i32Const(8), i32Const(8), i32Const(7), callFunc(emit), []byte{opDrop},
+		[]byte{opUnreachable})
+
+	store := createDB(t)
+	defer closeDB(t, store)
+	crtState, err := createContractState(t, store)
+	assert.NoError(t, err)
+
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender")}
+	receipt, err := call(m.bytes(), &types.CallInfo{Name: "emit"}, newExternalResolver(context, crtState))
+	assert.NoError(t, err)
+	assert.Equal(t, ReceiptSuccess, receipt.Status)
+	assert.Equal(t, []*Event{
+		{Name: "transfer", Data: []byte("payload")},
+		{Name: "transfer", Data: []byte("pay")},
+	}, receipt.Events)
+
+	receipt, err = call(m.bytes(), &types.CallInfo{Name: "fail"}, newExternalResolver(context, crtState))
+	assert.Error(t, err)
+	assert.Equal(t, ReceiptFailed, receipt.Status)
+	assert.True(t, receipt.GasUsed > 0)
+	assert.Empty(t, receipt.Events)
+}
+
+func TestSetReturn(t *testing.T) {
+	m := newTestModule()
+	setReturn := m.importFunc("_set_return", []byte{i32, i32}, []byte{i32})
+	m.putData(0, []byte("hello world"))
+	m.entry("query", i32Const(0), i32Const(5), callFunc(setReturn), []byte{opDrop},
+		i32Const(0), i32Const(11), callFunc(setReturn), []byte{opI64ExtendU})
+	m.entry("none", i64Const(0))
+
+	store := createDB(t)
+	defer closeDB(t, store)
+	crtState, err := createContractState(t, store)
+	assert.NoError(t, err)
+
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender")}
+	receipt, err := call(m.bytes(), &types.CallInfo{Name: "query"}, newExternalResolver(context, crtState))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", string(receipt.ReturnData))
+
+	receipt, err = call(m.bytes(), &types.CallInfo{Name: "none"}, newExternalResolver(context, crtState))
+	assert.NoError(t, err)
+	assert.Nil(t, receipt.ReturnData)
+}
+
+func TestResolveUnknownImport(t *testing.T) {
+	m := newTestModule()
+	unknown := m.importFunc("_unknown", []byte{i32}, []byte{i32})
+	m.entry("invoke", i32Const(0), callFunc(unknown), []byte{opI64ExtendU})
+
+	store := createDB(t)
+	defer closeDB(t, store)
+	crtState, err := createContractState(t, store)
+	assert.NoError(t, err)
+
+	context := &Context{gasLimit: 10000, senderAddress: []byte("sender")}
+	_, err = Create(crtState, context, deployCode(m.bytes(), nil))
+	assert.Equal(t, &ImportError{Module: "env", Field: "_unknown", Kind: "function"}, err)
+
+	_, err = call(m.bytes(), &types.CallInfo{Name: "invoke"}, newExternalResolver(context, crtState))
+	assert.Equal(t, &ImportError{Module: "env", Field: "_unknown", Kind: "function"}, err)
+}
+
+func TestHostPanic(t *testing.T) {
+	m := newTestModule()
+	getLen := m.importFunc("_get_len", []byte{i32, i32}, []byte{i32})
+	m.entry("invoke", i32Const(0), i32Const(0), callFunc(getLen), []byte{opI64ExtendU})
+
+	store := createDB(t)
+	defer closeDB(t, store)
+	crtState, err := createContractState(t, store)
+	assert.NoError(t, err)
+
+	resolver := newExternalResolver(&Context{gasLimit: 10000}, crtState)
+	vm, err := newVirtualMachine(m.bytes(), exec.VMConfig{DefaultMemoryPages: 1, GasLimit: 10000}, resolver)
+	assert.NoError(t, err)
+	vm.FunctionImports[getLen] = recoverHostCall("env", "_get_len", func(vm *exec.VirtualMachine) int64 {
+		panic("broken host")
+	})
+	entry, _ := vm.GetFunctionExport("invoke")
+	_, err = vm.Run(entry, 0, 0)
+	assert.Equal(t, &HostError{Module: "env", Field: "_get_len", Reason: "broken host"}, err)
+}
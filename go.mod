@@ -8,8 +8,10 @@ require (
 	github.com/aergoio/aergo v0.8.0
 	github.com/aergoio/aergo-lib v0.0.0-20181031015327-b69095212064
 	github.com/anaskhan96/base58check v0.0.0-20171020155424-fcff33ba49dd
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.0.1
 	github.com/dgryski/go-farm v0.0.0-20180109070241-2de33835d102 // indirect
 	github.com/dustin/go-humanize v1.0.0 // indirect
+	github.com/go-interpreter/wagon v0.0.0
 	github.com/golang/protobuf v1.2.0
 	github.com/golang/snappy v0.0.0-20180518054509-2e65f85255db // indirect
 	github.com/guptarohit/asciigraph v0.4.1 // indirect
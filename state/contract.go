@@ -16,6 +16,15 @@ func (mgr *Manager) OpenContractStateAccount(aid types.AccountID) (*ContractStat
 	return mgr.OpenContractState(st)
 }
 
+// GetABI returns the ABI of the contract account aid, nil if there is none
+func (mgr *Manager) GetABI(aid types.AccountID) ([]byte, error) {
+	crtState, err := mgr.OpenContractStateAccount(aid)
+	if err != nil {
+		return nil, err
+	}
+	return crtState.GetABI()
+}
+
 func (mgr *Manager) OpenContractState(crtState *types.State) (*ContractState, error) {
 	res := &ContractState{
 		State:   crtState,
@@ -59,11 +68,12 @@ func (mgr *Manager) CommitContractState(crtState *ContractState) error {
 
 type ContractState struct {
 	*types.State
-	code    []byte
-	storage *trie.Trie
-	buffer  *stateBuffer
-	store   *db.DB
-	hasher  func(data ...[]byte) []byte
+	code     []byte
+	codeHash []byte
+	storage  *trie.Trie
+	buffer   *stateBuffer
+	store    *db.DB
+	hasher   func(data ...[]byte) []byte
 }
 
 func (crtState *ContractState) SetNonce(nonce uint64) {
@@ -87,33 +97,90 @@ func (crtState *ContractState) SetCode(code []byte) error {
 		return err
 	}
 	crtState.State.CodeHash = codeHash[:]
+	crtState.code = code
+	crtState.codeHash = codeHash[:]
 	return nil
 }
+
+// GetCode returns the code of State.CodeHash, the code is reloaded
+// when the code hash is changed, e.g. by an upgrade or a rollback.
 func (crtState *ContractState) GetCode() ([]byte, error) {
-	if crtState.code != nil {
-		// already loaded.
-		return crtState.code, nil
-	}
 	codeHash := crtState.State.GetCodeHash()
 	if codeHash == nil {
 		// not defined. do nothing.
 		return nil, nil
 	}
-	err := loadData(crtState.store, crtState.State.CodeHash, &crtState.code)
+	if crtState.code != nil && bytes.Equal(crtState.codeHash, codeHash) {
+		// already loaded.
+		return crtState.code, nil
+	}
+	var code []byte
+	err := loadData(crtState.store, codeHash, &code)
 	if err != nil {
 		return nil, err
 	}
+	crtState.code = code
+	crtState.codeHash = codeHash
 	return crtState.code, nil
 }
 
+// abiID is the storage key of the contract ABI. It is not a hash output,
+// so no key a contract sets through SetData collides with it.
+var abiID = types.ToHash([]byte("zwasm.abi"))
+
+// SetABI stores the ABI of the contract under a reserved storage key
+func (crtState *ContractState) SetABI(abi []byte) error {
+	return crtState.buffer.put(abiID, abi)
+}
+
+// Clear discards the code, the storage and the balance of the contract
+func (crtState *ContractState) Clear() error {
+	crtState.State.CodeHash = nil
+	crtState.State.StorageRoot = nil
+	crtState.State.Balance = 0
+	crtState.code = nil
+	crtState.codeHash = nil
+	if crtState.storage != nil {
+		crtState.storage.Root = nil
+	}
+	return crtState.buffer.reset()
+}
+
+// DeleteABI removes the ABI of the contract
+func (crtState *ContractState) DeleteABI() error {
+	return crtState.buffer.delete(abiID)
+}
+
+// GetABI returns the ABI of the contract, nil if there is none
+func (crtState *ContractState) GetABI() ([]byte, error) {
+	return crtState.getData(abiID)
+}
+
 func (crtState *ContractState) SetData(key, value []byte) error {
 	return crtState.buffer.put(types.GetHash(key, crtState.hasher), value)
 }
 
+// DeleteData removes key from the contract storage.
+// the storage leaf is removed on CommitContractState.
+func (crtState *ContractState) DeleteData(key []byte) error {
+	return crtState.buffer.delete(types.GetHash(key, crtState.hasher))
+}
+
+// IsDirty reports whether key is changed in the contract state buffer
+func (crtState *ContractState) IsDirty(key []byte) bool {
+	return crtState.buffer.get(types.GetHash(key, crtState.hasher)) != nil
+}
+
 func (crtState *ContractState) GetData(key []byte) ([]byte, error) {
-	id := types.GetHash(key, crtState.hasher)
+	return crtState.getData(types.GetHash(key, crtState.hasher))
+}
+
+func (crtState *ContractState) getData(id types.Hash) ([]byte, error) {
 	entry := crtState.buffer.get(id)
 	if entry != nil {
+		if entry.isDeleted() {
+			return nil, nil
+		}
 		return entry.data.([]byte), nil
 	}
 	dkey, err := crtState.storage.Get(id[:])
@@ -130,3 +197,13 @@ func (crtState *ContractState) GetData(key []byte) ([]byte, error) {
 	}
 	return value, nil
 }
+
+// Snapshot returns revision number of contract state buffer
+func (crtState *ContractState) Snapshot() Snapshot {
+	return Snapshot(crtState.buffer.snapshot())
+}
+
+// Rollback discards changes of contract state buffer to revision number
+func (crtState *ContractState) Rollback(revision Snapshot) error {
+	return crtState.buffer.rollback(int(revision))
+}
@@ -34,6 +34,35 @@ func TestContractStateCode(t *testing.T) {
 	}
 }
 
+func TestContractStateCodeChange(t *testing.T) {
+	store := db.NewDB(db.BadgerImpl, t.Name())
+	hashFunc := common.HashFuncFactory("sha3")
+	manager := NewManager(&store, nil, hashFunc)
+	defer func() {
+		store.Close()
+		os.RemoveAll(t.Name())
+	}()
+	testAddress := []byte("test_address")
+	oldBytes := []byte("old_bytes")
+	newBytes := []byte("new_bytes")
+	contractState, err := manager.OpenContractStateAccount(types.ToAccountID(testAddress, hashFunc))
+	if err != nil {
+		t.Errorf("counld not open contract state : %s", err.Error())
+	}
+	contractState.SetCode(oldBytes)
+	oldHash := contractState.CodeHash
+	contractState.SetCode(newBytes)
+	res, err := contractState.GetCode()
+	if !bytes.Equal(res, newBytes) {
+		t.Errorf("different code detected : %s =/= %s", newBytes, string(res))
+	}
+	contractState.CodeHash = oldHash
+	res, err = contractState.GetCode()
+	if !bytes.Equal(res, oldBytes) {
+		t.Errorf("different code detected : %s =/= %s", oldBytes, string(res))
+	}
+}
+
 func TestContractStateData(t *testing.T) {
 	store := db.NewDB(db.BadgerImpl, t.Name())
 	hashFunc := common.HashFuncFactory("sha3")
@@ -125,3 +154,98 @@ func TestContractStateReOpenData(t *testing.T) {
 		t.Errorf("different data detected : %s =/= %s", testBytes, string(res2))
 	}
 }
+
+func TestContractStateDeleteData(t *testing.T) {
+	store := db.NewDB(db.BadgerImpl, t.Name())
+	hashFunc := common.HashFuncFactory("sha3")
+	manager := NewManager(&store, nil, hashFunc)
+	defer func() {
+		store.Close()
+		os.RemoveAll(t.Name())
+	}()
+	testAddress := []byte("test_address")
+	testBytes := []byte("test_bytes")
+	testKey := []byte("test_key")
+	otherKey := []byte("other_key")
+	contractState, err := manager.OpenContractStateAccount(types.ToAccountID(testAddress, hashFunc))
+	if err != nil {
+		t.Errorf("counld not open contract state : %s", err.Error())
+	}
+	contractState.SetData(testKey, testBytes)
+	contractState.SetData(otherKey, testBytes)
+	err = manager.CommitContractState(contractState)
+	if err != nil {
+		t.Errorf("counld commit contract state : %s", err.Error())
+	}
+	fullRoot := contractState.State.StorageRoot
+
+	contractState, err = manager.OpenContractState(contractState.State)
+	if err != nil {
+		t.Errorf("counld not open contract state : %s", err.Error())
+	}
+	err = contractState.DeleteData(testKey)
+	if err != nil {
+		t.Errorf("counld not delete contract data : %s", err.Error())
+	}
+	res, err := contractState.GetData(testKey)
+	if err != nil || res != nil {
+		t.Errorf("deleted data still exists : %s", string(res))
+	}
+	err = manager.CommitContractState(contractState)
+	if err != nil {
+		t.Errorf("counld commit contract state : %s", err.Error())
+	}
+	if bytes.Equal(fullRoot, contractState.State.StorageRoot) {
+		t.Errorf("storage root is not changed after delete")
+	}
+
+	contractState, err = manager.OpenContractState(contractState.State)
+	if err != nil {
+		t.Errorf("counld not open contract state : %s", err.Error())
+	}
+	res, err = contractState.GetData(testKey)
+	if err != nil || res != nil {
+		t.Errorf("deleted data still exists : %s", string(res))
+	}
+	res, err = contractState.GetData(otherKey)
+	if !bytes.Equal(res, testBytes) {
+		t.Errorf("different data detected : %s =/= %s", testBytes, string(res))
+	}
+}
+
+func TestContractStateABI(t *testing.T) {
+	store := db.NewDB(db.BadgerImpl, t.Name())
+	hashFunc := common.HashFuncFactory("sha3")
+	manager := NewManager(&store, nil, hashFunc)
+	defer func() {
+		store.Close()
+		os.RemoveAll(t.Name())
+	}()
+	testAddress := []byte("test_address")
+	testABI := []byte(`{"functions":[]}`)
+	contractState, err := manager.OpenContractStateAccount(types.ToAccountID(testAddress, hashFunc))
+	if err != nil {
+		t.Errorf("counld not open contract state : %s", err.Error())
+	}
+	err = contractState.SetABI(testABI)
+	if err != nil {
+		t.Errorf("counld set abi to contract state : %s", err.Error())
+	}
+	res, err := contractState.GetData([]byte("zwasm.abi"))
+	if err != nil || res != nil {
+		t.Errorf("abi is visible as contract data : %s", string(res))
+	}
+	err = manager.CommitContractState(contractState)
+	if err != nil {
+		t.Errorf("counld commit contract state : %s", err.Error())
+	}
+	err = manager.PutState(types.ToAccountID(testAddress, hashFunc), contractState.State)
+	if err != nil {
+		t.Errorf("counld put state : %s", err.Error())
+	}
+
+	res, err = manager.GetABI(types.ToAccountID(testAddress, hashFunc))
+	if err != nil || !bytes.Equal(res, testABI) {
+		t.Errorf("different abi detected : %s =/= %s", testABI, string(res))
+	}
+}
@@ -24,6 +24,7 @@ var (
 	errLoadRoot    = errors.New("failed to load root: invalid root")
 	errGetState    = errors.New("failed to get state: invalid account id")
 	errPutState    = errors.New("failed to put state: invalid account id")
+	errDeleteState = errors.New("failed to delete state: invalid account id")
 )
 
 type Manager struct {
@@ -53,6 +54,16 @@ func (mgr *Manager) Clone() *Manager {
 	return NewManager(mgr.store, mgr.GetRoot(), mgr.hasher)
 }
 
+// CloneOverlay returns a clone of mgr which keeps its writes in memory,
+// the store of mgr is not changed by the clone.
+func (mgr *Manager) CloneOverlay() *Manager {
+	mgr.lock.RLock()
+	defer mgr.lock.RUnlock()
+
+	var store db.DB = newOverlayDB(*mgr.store)
+	return NewManager(&store, mgr.GetRoot(), mgr.hasher)
+}
+
 // GetRoot returns root dataHash of trie
 func (mgr *Manager) GetRoot() []byte {
 	mgr.lock.RLock()
@@ -116,6 +127,17 @@ func (mgr *Manager) PutState(id types.AccountID, state *types.State) error {
 	return mgr.buffer.put(types.Hash(id), state)
 }
 
+// DeleteState puts a tombstone for account id into state buffer,
+// the account leaf is removed from trie on Update.
+func (mgr *Manager) DeleteState(id types.AccountID) error {
+	mgr.lock.Lock()
+	defer mgr.lock.Unlock()
+	if id == types.EmptyAccountID {
+		return errDeleteState
+	}
+	return mgr.buffer.delete(types.Hash(id))
+}
+
 // GetAccountState gets state of account id from state manager.
 // empty state is returned when there is no state corresponding to account id.
 func (mgr *Manager) GetAccountState(aid types.AccountID) (*types.State, error) {
@@ -195,6 +217,42 @@ func (mgr *Manager) CreateRolledAccountState(id []byte) (*RolledState, error) {
 	return v, nil
 }
 
+// CreateContractAccount returns the rolled state of a fresh account for a contract
+// of creator. The address is derived from the creator nonce, which is incremented.
+func (mgr *Manager) CreateContractAccount(creator []byte) (*RolledState, error) {
+	return mgr.createContractAccount(creator, func(nonce uint64) types.Address {
+		return types.NewContractAddress(creator, nonce, mgr.hasher)
+	})
+}
+
+// CreateContractAccountWithSalt is like CreateContractAccount but the address is
+// derived from salt and the hash of the deployment code instead of the creator nonce.
+func (mgr *Manager) CreateContractAccountWithSalt(creator []byte, salt []byte, code []byte) (*RolledState, error) {
+	return mgr.createContractAccount(creator, func(uint64) types.Address {
+		return types.NewSaltedContractAddress(creator, salt, mgr.hasher(code), mgr.hasher)
+	})
+}
+
+func (mgr *Manager) createContractAccount(creator []byte, address func(nonce uint64) types.Address) (*RolledState, error) {
+	if len(creator) == 0 {
+		return nil, errInvalidArgs
+	}
+	owner, err := mgr.GetRolledAccountState(creator)
+	if err != nil {
+		return nil, err
+	}
+	nonce := owner.State().GetNonce()
+	v, err := mgr.CreateRolledAccountState(address(nonce))
+	if err != nil {
+		return nil, err
+	}
+	owner.SetNonce(nonce + 1)
+	if err = owner.PutState(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
 func (mgr *Manager) GetRolledAccountState(id []byte) (*RolledState, error) {
 	aid := types.ToAccountID(id, mgr.hasher)
 	st, err := mgr.GetState(aid)
@@ -233,6 +291,9 @@ func (mgr *Manager) GetState(id types.AccountID) (*types.State, error) {
 	// get state from buffer
 	entry := mgr.buffer.get(types.Hash(id))
 	if entry != nil {
+		if entry.isDeleted() {
+			return nil, nil
+		}
 		return entry.getData().(*types.State), nil
 	}
 	// get state from trie
@@ -168,6 +168,51 @@ func TestStateDBUpdateAndCommit(t *testing.T) {
 	assert.Equal(t, testRoot, manager.GetRoot())
 }
 
+func TestStateDBDeleteState(t *testing.T) {
+	store := db.NewDB(db.BadgerImpl, t.Name())
+	hashFunc := common.HashFuncFactory("sha3")
+	manager := NewManager(&store, nil, hashFunc)
+	defer func() {
+		store.Close()
+		os.RemoveAll(t.Name())
+	}()
+	otherAccount := types.ToAccountID([]byte("other_address"), hashFunc)
+
+	_ = manager.PutState(otherAccount, &testSecondStates[0])
+	_ = manager.Update()
+	_ = manager.Commit()
+	otherRoot := manager.GetRoot()
+
+	_ = manager.PutState(testAccount, &testStates[0])
+	_ = manager.Update()
+	_ = manager.Commit()
+	assert.NotEqual(t, otherRoot, manager.GetRoot())
+
+	err := manager.DeleteState(testAccount)
+	if err != nil {
+		t.Errorf("failed to delete state: %v", err.Error())
+	}
+	st, err := manager.GetState(testAccount)
+	assert.NoError(t, err)
+	assert.Nil(t, st)
+
+	err = manager.Update()
+	if err != nil {
+		t.Errorf("failed to update: %v", err.Error())
+	}
+	_ = manager.Commit()
+	assert.Equal(t, otherRoot, manager.GetRoot())
+
+	st, err = manager.GetState(testAccount)
+	assert.NoError(t, err)
+	assert.Nil(t, st)
+	st, err = manager.GetState(otherAccount)
+	assert.NoError(t, err)
+	assert.True(t, stateEquals(&testSecondStates[0], st))
+
+	assert.Equal(t, errDeleteState, manager.DeleteState(types.EmptyAccountID))
+}
+
 func TestStateDBSetRoot(t *testing.T) {
 	store := db.NewDB(db.BadgerImpl, t.Name())
 	hashFunc := common.HashFuncFactory("sha3")
@@ -262,3 +307,43 @@ func TestStateDBParallel(t *testing.T) {
 	}
 	assert.True(t, stateEquals(&testStates[4], st2))
 }
+
+func TestStateDBCreateContractAccount(t *testing.T) {
+	store := db.NewDB(db.BadgerImpl, t.Name())
+	hashFunc := common.HashFuncFactory("sha3")
+	manager := NewManager(&store, nil, hashFunc)
+	defer func() {
+		store.Close()
+		os.RemoveAll(t.Name())
+	}()
+	creator := []byte("creator")
+
+	first, err := manager.CreateContractAccount(creator)
+	assert.NoError(t, err)
+	assert.Equal(t, types.NewContractAddress(creator, 0, hashFunc), types.Address(first.ID()))
+	assert.Len(t, first.ID(), types.ContractAddressLength)
+	assert.True(t, first.IsCreate())
+	_ = first.PutState()
+
+	second, err := manager.CreateContractAccount(creator)
+	assert.NoError(t, err)
+	assert.Equal(t, types.NewContractAddress(creator, 1, hashFunc), types.Address(second.ID()))
+	assert.NotEqual(t, first.ID(), second.ID())
+
+	owner, err := manager.GetRolledAccountState(creator)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), owner.State().GetNonce())
+
+	salted, err := manager.CreateContractAccountWithSalt(creator, []byte("salt"), []byte("code"))
+	assert.NoError(t, err)
+	assert.Equal(t, types.NewSaltedContractAddress(creator, []byte("salt"), hashFunc([]byte("code")), hashFunc), types.Address(salted.ID()))
+	_ = salted.PutState()
+
+	_, err = manager.CreateContractAccountWithSalt(creator, []byte("salt"), []byte("code"))
+	assert.Error(t, err)
+	owner, _ = manager.GetRolledAccountState(creator)
+	assert.Equal(t, uint64(3), owner.State().GetNonce())
+
+	_, err = manager.CreateContractAccount(nil)
+	assert.Equal(t, errInvalidArgs, err)
+}
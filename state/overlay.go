@@ -0,0 +1,153 @@
+package state
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"github.com/aergoio/aergo-lib/db"
+)
+
+// overlayDB keeps the writes to a store in memory, reads fall back to the store.
+// A nil value marks a deleted key.
+type overlayDB struct {
+	lock sync.RWMutex
+	base db.DB
+	data map[string][]byte
+}
+
+func newOverlayDB(base db.DB) *overlayDB {
+	return &overlayDB{base: base, data: make(map[string][]byte)}
+}
+
+func (o *overlayDB) Type() string {
+	return "overlay"
+}
+
+func (o *overlayDB) Set(key, value []byte) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if value == nil {
+		value = []byte{}
+	}
+	o.data[string(key)] = append([]byte{}, value...)
+}
+
+func (o *overlayDB) Delete(key []byte) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.data[string(key)] = nil
+}
+
+func (o *overlayDB) Get(key []byte) []byte {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	if value, ok := o.data[string(key)]; ok {
+		if value == nil {
+			return []byte{}
+		}
+		return value
+	}
+	return o.base.Get(key)
+}
+
+func (o *overlayDB) Exist(key []byte) bool {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	if value, ok := o.data[string(key)]; ok {
+		return value != nil
+	}
+	return o.base.Exist(key)
+}
+
+// Iterator returns the keys in [start, end) of the overlay and the store in order
+func (o *overlayDB) Iterator(start, end []byte) db.Iterator {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	entries := make(map[string][]byte)
+	for iter := o.base.Iterator(start, end); iter.Valid(); iter.Next() {
+		entries[string(iter.Key())] = iter.Value()
+	}
+	for key, value := range o.data {
+		if !inRange([]byte(key), start, end) {
+			continue
+		}
+		if value == nil {
+			delete(entries, key)
+			continue
+		}
+		entries[key] = value
+	}
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return &overlayIterator{keys: keys, entries: entries}
+}
+
+func inRange(key, start, end []byte) bool {
+	if start != nil && bytes.Compare(key, start) < 0 {
+		return false
+	}
+	return end == nil || bytes.Compare(key, end) < 0
+}
+
+func (o *overlayDB) NewTx() db.Transaction {
+	return &overlayTx{db: o, data: make(map[string][]byte)}
+}
+
+// Close leaves the store open, it is owned by the Manager which was cloned
+func (o *overlayDB) Close() {
+}
+
+type overlayTx struct {
+	db   *overlayDB
+	data map[string][]byte
+}
+
+func (tx *overlayTx) Set(key, value []byte) {
+	if value == nil {
+		value = []byte{}
+	}
+	tx.data[string(key)] = append([]byte{}, value...)
+}
+
+func (tx *overlayTx) Delete(key []byte) {
+	tx.data[string(key)] = nil
+}
+
+func (tx *overlayTx) Commit() {
+	tx.db.lock.Lock()
+	defer tx.db.lock.Unlock()
+	for key, value := range tx.data {
+		tx.db.data[key] = value
+	}
+	tx.data = nil
+}
+
+func (tx *overlayTx) Discard() {
+	tx.data = nil
+}
+
+type overlayIterator struct {
+	keys    []string
+	entries map[string][]byte
+	index   int
+}
+
+func (iter *overlayIterator) Next() {
+	iter.index++
+}
+
+func (iter *overlayIterator) Valid() bool {
+	return iter.index < len(iter.keys)
+}
+
+func (iter *overlayIterator) Key() []byte {
+	return []byte(iter.keys[iter.index])
+}
+
+func (iter *overlayIterator) Value() []byte {
+	return iter.entries[iter.keys[iter.index]]
+}
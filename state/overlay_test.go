@@ -0,0 +1,61 @@
+package state
+
+import (
+	"os"
+	"testing"
+
+	"github.com/aergoio/aergo-lib/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhigui-projects/zwasm/common"
+)
+
+func TestOverlayDB(t *testing.T) {
+	store := db.NewDB(db.BadgerImpl, t.Name())
+	defer func() {
+		store.Close()
+		os.RemoveAll(t.Name())
+	}()
+	store.Set([]byte("a"), []byte("1"))
+	store.Set([]byte("b"), []byte("2"))
+
+	overlay := newOverlayDB(store)
+	overlay.Set([]byte("c"), []byte("3"))
+	overlay.Delete([]byte("a"))
+	tx := overlay.NewTx()
+	tx.Set([]byte("b"), []byte("4"))
+	tx.Commit()
+
+	assert.False(t, overlay.Exist([]byte("a")))
+	assert.Equal(t, []byte("4"), overlay.Get([]byte("b")))
+	assert.Equal(t, []byte("3"), overlay.Get([]byte("c")))
+	var keys []string
+	for iter := overlay.Iterator(nil, nil); iter.Valid(); iter.Next() {
+		keys = append(keys, string(iter.Key()))
+	}
+	assert.Equal(t, []string{"b", "c"}, keys)
+
+	assert.Equal(t, []byte("1"), store.Get([]byte("a")))
+	assert.Equal(t, []byte("2"), store.Get([]byte("b")))
+	assert.False(t, store.Exist([]byte("c")))
+}
+
+func TestStateDBCloneOverlay(t *testing.T) {
+	store := db.NewDB(db.BadgerImpl, t.Name())
+	hashFunc := common.HashFuncFactory("sha3")
+	manager := NewManager(&store, nil, hashFunc)
+	defer func() {
+		store.Close()
+		os.RemoveAll(t.Name())
+	}()
+
+	clone := manager.CloneOverlay()
+	for _, v := range testStates {
+		_ = clone.PutState(testAccount, &v)
+	}
+	assert.NoError(t, clone.Update())
+	assert.NoError(t, clone.Commit())
+	assert.Equal(t, testRoot, clone.GetRoot())
+
+	assert.Nil(t, manager.GetRoot())
+	assert.False(t, store.Iterator(nil, nil).Valid())
+}
@@ -4,6 +4,7 @@ import (
 	"sort"
 
 	"github.com/aergoio/aergo-lib/db"
+	"github.com/aergoio/aergo/pkg/trie"
 	"github.com/golang/protobuf/proto"
 	"github.com/zhigui-projects/zwasm/types"
 )
@@ -34,6 +35,9 @@ func (et *bufferEntry) getHash() []byte {
 func (et *bufferEntry) getData() interface{} {
 	return et.data
 }
+func (et *bufferEntry) isDeleted() bool {
+	return et.data == nil
+}
 
 type stack []int
 
@@ -129,6 +133,17 @@ func (buffer *stateBuffer) put(key types.Hash, data interface{}) error {
 	return nil
 }
 
+// delete puts a tombstone for key into the buffer.
+// the key is removed from trie when the buffer is exported.
+func (buffer *stateBuffer) delete(key types.Hash) error {
+	snapshot := buffer.snapshot()
+	et := newBufferEntry(key, types.EmptyHash, nil)
+	buffer.entries = append(buffer.entries, *et)
+	buffer.indexes[key] = buffer.indexes[key].push(snapshot)
+	buffer.nextIdx++
+	return nil
+}
+
 func (buffer *stateBuffer) snapshot() int {
 	return buffer.nextIdx
 }
@@ -168,6 +183,10 @@ func (buffer *stateBuffer) export() ([][]byte, [][]byte) {
 	vals := make([][]byte, size)
 	for i, et := range bufs {
 		keys[i] = append(keys[i], et.getKey()...)
+		if et.isDeleted() {
+			vals[i] = append(vals[i], trie.DefaultLeaf...)
+			continue
+		}
 		vals[i] = append(vals[i], et.getHash()...)
 	}
 	return keys, vals
@@ -177,6 +196,9 @@ func (buffer *stateBuffer) commit(store *db.DB) error {
 	dbtx := (*store).NewTx()
 	for _, v := range buffer.indexes {
 		et := buffer.entries[v.peek()]
+		if et.isDeleted() {
+			continue
+		}
 		buf, err := marshal(et.data)
 		if err != nil {
 			dbtx.Discard()
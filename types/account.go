@@ -1,6 +1,7 @@
 package types
 
 import (
+	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -45,3 +46,25 @@ func (address Base58Address) DecodeAddress(encodedAddr string) (Address, error)
 	decoded := decodedBytes[1:]
 	return decoded, nil
 }
+
+// ContractAddressLength is the length of a derived contract address
+const ContractAddressLength = 20
+
+// NewContractAddress derives the address of the contract which creator deploys with nonce
+func NewContractAddress(creator Address, nonce uint64, hashFunc func(data ...[]byte) []byte) Address {
+	nonceBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(nonceBytes, nonce)
+	return toContractAddress(hashFunc([]byte{0x00}, creator, nonceBytes))
+}
+
+// NewSaltedContractAddress derives the address of the contract which creator deploys
+// with salt and the deployment code of codeHash, regardless of the creator nonce.
+func NewSaltedContractAddress(creator Address, salt []byte, codeHash []byte, hashFunc func(data ...[]byte) []byte) Address {
+	return toContractAddress(hashFunc([]byte{0xff}, hashFunc(creator), hashFunc(salt), codeHash))
+}
+
+func toContractAddress(hash []byte) Address {
+	addr := make([]byte, ContractAddressLength)
+	copy(addr, hash[len(hash)-ContractAddressLength:])
+	return addr
+}